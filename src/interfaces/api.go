@@ -0,0 +1,429 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// apiApp is the JSON representation of an AppDeploymentInfo
+	apiApp struct {
+		// ID is the name of the vistecture app, Name the one of the kubernetes resource
+		ID                     string            `json:"id"`
+		Name                   string            `json:"name"`
+		Namespace              string            `json:"namespace,omitempty"`
+		Environment            string            `json:"environment"`
+		Title                  string            `json:"title,omitempty"`
+		Team                   string            `json:"team,omitempty"`
+		Group                  string            `json:"group,omitempty"`
+		State                  string            `json:"state"`
+		StateReason            string            `json:"stateReason,omitempty"`
+		OwnState               string            `json:"ownState,omitempty"`
+		ImpactedBy             []string          `json:"impactedBy,omitempty"`
+		Impacts                []string          `json:"impacts,omitempty"`
+		HealthCheckType        string            `json:"healthCheckType,omitempty"`
+		HealthyAlsoFromIngress bool              `json:"healthyAlsoFromIngress"`
+		HealthcheckPath        string            `json:"healthcheckPath,omitempty"`
+		HealthCheckServices    []apiService      `json:"healthCheckServices,omitempty"`
+		ApiDocumentationUrl    string            `json:"apiDocumentationUrl,omitempty"`
+		K8sType                string            `json:"k8sType,omitempty"`
+		Replicas               int32             `json:"replicas"`
+		DesiredReplicas        int32             `json:"desiredReplicas"`
+		ReadyReplicas          int32             `json:"readyReplicas"`
+		AvailableReplicas      int32             `json:"availableReplicas"`
+		ObservedGeneration     int64             `json:"observedGeneration"`
+		CronJob                *apiCronJob       `json:"cronJob,omitempty"`
+		Pods                   []apiPod          `json:"pods,omitempty"`
+		Events                 []apiEvent        `json:"events,omitempty"`
+		Images                 []apiImage        `json:"images"`
+		Ingresses              []apiIngress      `json:"ingresses"`
+		Labels                 map[string]string `json:"labels,omitempty"`
+		CheckedAt              time.Time         `json:"checkedAt"`
+		CheckDurationMs        int64             `json:"checkDurationMs,omitempty"`
+		IngressCheckDurationMs int64             `json:"ingressCheckDurationMs,omitempty"`
+		Stale                  bool              `json:"stale"`
+	}
+
+	apiCronJob struct {
+		Schedule           string     `json:"schedule"`
+		TimeZone           string     `json:"timeZone,omitempty"`
+		Suspended          bool       `json:"suspended"`
+		ActiveJobs         int        `json:"activeJobs"`
+		LastScheduleTime   *time.Time `json:"lastScheduleTime"`
+		LastSuccessfulTime *time.Time `json:"lastSuccessfulTime"`
+		NextScheduleTime   *time.Time `json:"nextScheduleTime"`
+		MissedSchedule     bool       `json:"missedSchedule"`
+	}
+
+	apiPod struct {
+		Name                  string     `json:"name"`
+		Phase                 string     `json:"phase"`
+		Ready                 bool       `json:"ready"`
+		Restarts              int32      `json:"restarts"`
+		WaitingReason         string     `json:"waitingReason,omitempty"`
+		LastTerminationReason string     `json:"lastTerminationReason,omitempty"`
+		LastTerminationTime   *time.Time `json:"lastTerminationTime,omitempty"`
+	}
+
+	apiEvent struct {
+		Time    time.Time `json:"time"`
+		Reason  string    `json:"reason"`
+		Message string    `json:"message"`
+		Object  string    `json:"object"`
+		Count   int32     `json:"count"`
+	}
+
+	// apiService is a dependency reported by the healthcheck of the app
+	apiService struct {
+		Name    string `json:"name"`
+		Alive   bool   `json:"alive"`
+		Details string `json:"details,omitempty"`
+	}
+
+	apiImage struct {
+		Version  string `json:"version"`
+		FullPath string `json:"fullPath"`
+	}
+
+	apiIngress struct {
+		URL  string `json:"url"`
+		Host string `json:"host"`
+		Path string `json:"path"`
+	}
+
+	// apiSummary holds the number of apps per state
+	apiSummary struct {
+		Total  int            `json:"total"`
+		States map[string]int `json:"states"`
+		// RootCauses are the apps impacting others
+		RootCauses  []string  `json:"rootCauses"`
+		GeneratedAt time.Time `json:"generatedAt"`
+		// Fetch holds the fetch status per environment
+		Fetch map[string]apiFetchStatus `json:"fetch"`
+	}
+
+	// apiFetchStatus tells if the results are outdated because kubernetes could not be reached
+	apiFetchStatus struct {
+		Stale               bool      `json:"stale"`
+		LastSuccess         time.Time `json:"lastSuccess"`
+		AgeSeconds          int       `json:"ageSeconds"`
+		Error               string    `json:"error,omitempty"`
+		ConsecutiveFailures int       `json:"consecutiveFailures"`
+	}
+
+	apiError struct {
+		Error string `json:"error"`
+	}
+
+	// appFilter restricts the apps returned by the api, empty fields match everything
+	appFilter struct {
+		// Applications are the names of the vistecture apps of a sub view, team or group, nil matches all apps
+		Applications []string
+		States       []uint
+		Team         string
+		Group        string
+		Namespace    string
+		Environment  string
+	}
+)
+
+// apiAppsHandler lists all apps of all environments matching the filter given in the query
+func (d *DashboardController) apiAppsHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: r.URL.Path + " not found"})
+		return
+	}
+
+	filter, err := appFilterFromRequest(r)
+	if err != nil {
+		writeJSON(rw, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	filter.Applications = current.applications()
+
+	result := []apiApp{}
+	for _, statusFetcher := range envs {
+		if filter.Environment != "" && filter.Environment != statusFetcher.Environment() {
+			continue
+		}
+
+		fetchStatus := statusFetcher.GetFetchStatus()
+		for _, deployment := range filter.apply(statusFetcher.GetCurrentResult()) {
+			result = append(result, toApiApp(deployment, fetchStatus))
+		}
+	}
+
+	writeJSON(rw, http.StatusOK, result)
+}
+
+// apiAppHandler returns a single app by its vistecture or kubernetes name from the environment given in the query (or the default)
+func (d *DashboardController) apiAppHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "environment " + r.URL.Query().Get("environment") + " not found"})
+		return
+	}
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: r.URL.Path + " not found"})
+		return
+	}
+
+	name := r.PathValue("name")
+	deployment, ok := findApp(statusFetcher.GetCurrentResult(), name)
+	if ok && current != nil {
+		ok = slices.Contains(current.Applications, deployment.VistectureApp.Name)
+	}
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "app " + name + " not found"})
+		return
+	}
+
+	writeJSON(rw, http.StatusOK, toApiApp(deployment, statusFetcher.GetFetchStatus()))
+}
+
+// apiSummaryHandler returns the number of apps per state for all apps matching the filter given in the query
+func (d *DashboardController) apiSummaryHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: r.URL.Path + " not found"})
+		return
+	}
+
+	filter, err := appFilterFromRequest(r)
+	if err != nil {
+		writeJSON(rw, http.StatusBadRequest, apiError{Error: err.Error()})
+		return
+	}
+	filter.Applications = current.applications()
+
+	var deployments []kube.AppDeploymentInfo
+	fetchStatus := make(map[string]apiFetchStatus)
+	for _, statusFetcher := range envs {
+		if filter.Environment != "" && filter.Environment != statusFetcher.Environment() {
+			continue
+		}
+
+		deployments = append(deployments, filter.apply(statusFetcher.GetCurrentResult())...)
+		fetchStatus[statusFetcher.Environment()] = toApiFetchStatus(statusFetcher.GetFetchStatus())
+	}
+
+	summary := summarize(deployments)
+	summary.Fetch = fetchStatus
+	writeJSON(rw, http.StatusOK, summary)
+}
+
+// findApp looks up the app by its vistecture name first and falls back to the kubernetes name
+func findApp(result map[string]kube.AppDeploymentInfo, name string) (kube.AppDeploymentInfo, bool) {
+	if deployment, ok := result[name]; ok {
+		return deployment, true
+	}
+
+	for _, deployment := range result {
+		if deployment.Name == name {
+			return deployment, true
+		}
+	}
+
+	return kube.AppDeploymentInfo{}, false
+}
+
+// appFilterFromRequest reads the filter from the query, states can be passed multiple times or comma separated
+func appFilterFromRequest(r *http.Request) (appFilter, error) {
+	query := r.URL.Query()
+	filter := appFilter{
+		Team:        query.Get("team"),
+		Group:       query.Get("group"),
+		Namespace:   query.Get("namespace"),
+		Environment: query.Get("environment"),
+	}
+
+	for _, states := range query["state"] {
+		for _, name := range strings.Split(states, ",") {
+			state, ok := kube.StateByName(strings.TrimSpace(name))
+			if !ok {
+				return filter, fmt.Errorf("unknown state %q", name)
+			}
+			filter.States = append(filter.States, state)
+		}
+	}
+
+	return filter, nil
+}
+
+// apply returns all matching deployments sorted by name
+func (f appFilter) apply(result map[string]kube.AppDeploymentInfo) []kube.AppDeploymentInfo {
+	var deployments []kube.AppDeploymentInfo
+	for _, deployment := range result {
+		if f.matches(deployment) {
+			deployments = append(deployments, deployment)
+		}
+	}
+
+	sort.Sort(ByName(deployments))
+	return deployments
+}
+
+func (f appFilter) matches(deployment kube.AppDeploymentInfo) bool {
+	if f.Applications != nil && !slices.Contains(f.Applications, deployment.VistectureApp.Name) {
+		return false
+	}
+
+	if f.Team != "" && !strings.EqualFold(deployment.VistectureApp.Team, f.Team) {
+		return false
+	}
+
+	if f.Group != "" && !strings.EqualFold(deployment.VistectureApp.Group, f.Group) {
+		return false
+	}
+
+	if f.Namespace != "" && deployment.Namespace != f.Namespace {
+		return false
+	}
+
+	if len(f.States) == 0 {
+		return true
+	}
+
+	for _, state := range f.States {
+		if deployment.AppStateInfo.State == state {
+			return true
+		}
+	}
+
+	return false
+}
+
+func summarize(deployments []kube.AppDeploymentInfo) apiSummary {
+	summary := apiSummary{
+		Total:       len(deployments),
+		States:      make(map[string]int),
+		RootCauses:  []string{},
+		GeneratedAt: time.Now(),
+	}
+
+	for _, deployment := range deployments {
+		summary.States[kube.StateName(deployment.AppStateInfo.State)]++
+		if len(deployment.AppStateInfo.Impacts) > 0 && !slices.Contains(summary.RootCauses, deployment.VistectureApp.Name) {
+			summary.RootCauses = append(summary.RootCauses, deployment.VistectureApp.Name)
+		}
+	}
+	sort.Strings(summary.RootCauses)
+
+	return summary
+}
+
+func toApiFetchStatus(fetchStatus kube.FetchStatus) apiFetchStatus {
+	return apiFetchStatus{
+		Stale:               fetchStatus.Stale(),
+		LastSuccess:         fetchStatus.LastSuccess,
+		AgeSeconds:          int(fetchStatus.Age().Seconds()),
+		Error:               fetchStatus.LastError,
+		ConsecutiveFailures: fetchStatus.ConsecutiveFailures,
+	}
+}
+
+func toApiApp(deployment kube.AppDeploymentInfo, fetchStatus kube.FetchStatus) apiApp {
+	app := apiApp{
+		ID:                     deployment.VistectureApp.Name,
+		Name:                   deployment.Name,
+		Namespace:              deployment.Namespace,
+		Environment:            deployment.Environment,
+		Title:                  deployment.VistectureApp.Title,
+		Team:                   deployment.VistectureApp.Team,
+		Group:                  deployment.VistectureApp.Group,
+		State:                  kube.StateName(deployment.AppStateInfo.State),
+		StateReason:            deployment.AppStateInfo.StateReason,
+		ImpactedBy:             deployment.AppStateInfo.ImpactedBy,
+		Impacts:                deployment.AppStateInfo.Impacts,
+		HealthCheckType:        deployment.AppStateInfo.HealthCheckType,
+		HealthyAlsoFromIngress: deployment.AppStateInfo.HealthyAlsoFromIngress,
+		HealthcheckPath:        deployment.HealthcheckPath,
+		ApiDocumentationUrl:    deployment.ApiDocumentationUrl,
+		K8sType:                deployment.K8sType,
+		Replicas:               deployment.Workload.Current,
+		DesiredReplicas:        deployment.Workload.Desired,
+		ReadyReplicas:          deployment.Workload.Ready,
+		AvailableReplicas:      deployment.Workload.Available,
+		ObservedGeneration:     deployment.Workload.ObservedGeneration,
+		Images:                 make([]apiImage, 0, len(deployment.Images)),
+		Ingresses:              make([]apiIngress, 0, len(deployment.Ingress)),
+		Labels:                 deployment.Labels,
+		CheckedAt:              deployment.AppStateInfo.CheckedAt,
+		CheckDurationMs:        deployment.AppStateInfo.CheckDuration.Milliseconds(),
+		IngressCheckDurationMs: deployment.AppStateInfo.IngressCheckDuration.Milliseconds(),
+		Stale:                  fetchStatus.Stale(),
+	}
+
+	if deployment.AppStateInfo.State == kube.State_impacted {
+		app.OwnState = kube.StateName(deployment.AppStateInfo.OwnState)
+	}
+
+	for _, service := range deployment.HealthCheckServices {
+		app.HealthCheckServices = append(app.HealthCheckServices, apiService{Name: service.Name, Alive: service.Alive, Details: service.Details})
+	}
+
+	for _, image := range deployment.Images {
+		app.Images = append(app.Images, apiImage{Version: image.Version, FullPath: image.FullPath})
+	}
+
+	for _, ingress := range deployment.Ingress {
+		app.Ingresses = append(app.Ingresses, apiIngress{URL: ingress.URL, Host: ingress.Host, Path: ingress.Path})
+	}
+
+	for _, pod := range deployment.Pods {
+		app.Pods = append(app.Pods, apiPod{
+			Name:                  pod.Name,
+			Phase:                 pod.Phase,
+			Ready:                 pod.Ready,
+			Restarts:              pod.Restarts,
+			WaitingReason:         pod.WaitingReason,
+			LastTerminationReason: pod.LastTerminationReason,
+			LastTerminationTime:   optionalTime(pod.LastTerminationTime),
+		})
+	}
+
+	for _, event := range deployment.Events {
+		app.Events = append(app.Events, apiEvent{Time: event.Time, Reason: event.Reason, Message: event.Message, Object: event.Object, Count: event.Count})
+	}
+
+	if cronJob := deployment.CronJob; cronJob != nil {
+		app.CronJob = &apiCronJob{
+			Schedule:           cronJob.Schedule,
+			TimeZone:           cronJob.TimeZone,
+			Suspended:          cronJob.Suspended,
+			ActiveJobs:         cronJob.ActiveJobs,
+			LastScheduleTime:   optionalTime(cronJob.LastScheduleTime),
+			LastSuccessfulTime: optionalTime(cronJob.LastSuccessfulTime),
+			NextScheduleTime:   optionalTime(cronJob.NextScheduleTime),
+			MissedSchedule:     cronJob.MissedSchedule,
+		}
+	}
+
+	return app
+}
+
+// optionalTime returns nil for the zero time, to be encoded as null
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// writeJSON encodes v as response body
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("content-type", "application/json")
+	rw.WriteHeader(status)
+	encoder := json.NewEncoder(rw)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
@@ -0,0 +1,81 @@
+package interfaces
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestAppFilter(t *testing.T) {
+	result := map[string]kube.AppDeploymentInfo{
+		"flamingo": {
+			Name:          "flamingo",
+			AppStateInfo:  kube.AppStateInfo{State: kube.State_failed},
+			VistectureApp: vistectureCore.Application{Team: "Team 1"},
+		},
+		"akeneo": {
+			Name:          "akeneo",
+			AppStateInfo:  kube.AppStateInfo{State: kube.State_healthy},
+			VistectureApp: vistectureCore.Application{Team: "Team 2"},
+		},
+		"keycloak": {
+			Name:         "keycloak",
+			AppStateInfo: kube.AppStateInfo{State: kube.State_unhealthy},
+		},
+	}
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"akeneo", "flamingo", "keycloak"}},
+		{"state=failed", []string{"flamingo"}},
+		{"state=failed,unhealthy", []string{"flamingo", "keycloak"}},
+		{"state=failed&state=healthy", []string{"akeneo", "flamingo"}},
+		{"team=team+2", []string{"akeneo"}},
+		{"team=Team+1&state=healthy", nil},
+	}
+
+	for _, testCase := range testCases {
+		filter, err := appFilterFromRequest(httptest.NewRequest("GET", "/api/v1/apps?"+testCase.query, nil))
+		if err != nil {
+			t.Fatalf("query %q: unexpected error %v", testCase.query, err)
+		}
+
+		var names []string
+		for _, deployment := range filter.apply(result) {
+			names = append(names, deployment.Name)
+		}
+
+		if len(names) != len(testCase.expected) {
+			t.Fatalf("query %q: expected %v, got %v", testCase.query, testCase.expected, names)
+		}
+		for i := range names {
+			if names[i] != testCase.expected[i] {
+				t.Errorf("query %q: expected %v, got %v", testCase.query, testCase.expected, names)
+			}
+		}
+	}
+}
+
+func TestAppFilter_UnknownState(t *testing.T) {
+	_, err := appFilterFromRequest(httptest.NewRequest("GET", "/api/v1/apps?state=broken", nil))
+	if err == nil {
+		t.Error("expected an error for an unknown state")
+	}
+}
+
+func TestToApiApp_Replicas(t *testing.T) {
+	deployment := kube.AppDeploymentInfo{
+		Name:     "flamingo",
+		Workload: kube.WorkloadStatus{Desired: 3, Current: 4, Ready: 2, Available: 1, ObservedGeneration: 7},
+	}
+
+	app := toApiApp(deployment, kube.FetchStatus{})
+	if app.Replicas != 4 || app.DesiredReplicas != 3 || app.ReadyReplicas != 2 || app.AvailableReplicas != 1 || app.ObservedGeneration != 7 {
+		t.Errorf("expected replicas 4, desired 3, ready 2, available 1 and generation 7, got %+v", app)
+	}
+}
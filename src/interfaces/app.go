@@ -0,0 +1,248 @@
+package interfaces
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+// defaultTimelinePeriod is the period of the timeline of the app page without since parameter
+const defaultTimelinePeriod = 7 * 24 * time.Hour
+
+type (
+	// appData is everything known about a single app
+	appData struct {
+		layoutData
+		App  kube.AppDeploymentInfo
+		Spec *workloadSpec
+		// HistoryEnabled is false if no history file is configured, the Timeline is empty then
+		HistoryEnabled bool
+		Since          time.Time
+		Timeline       []transition
+	}
+
+	// workloadSpec summarizes the spec of a deployment, stateful set or daemon set
+	workloadSpec struct {
+		Kind           string
+		Strategy       string
+		Selector       string
+		ServiceAccount string
+		Containers     []containerSpec
+	}
+
+	containerSpec struct {
+		Name     string
+		Image    string
+		Ports    []string
+		Requests string
+		Limits   string
+		// Probes describe the liveness, readiness and startup probes, e.g. "readiness: GET :8080/health"
+		Probes []string
+	}
+
+	// transition is a period in which the app had the same state, End is zero for the current one
+	transition struct {
+		State      uint
+		OwnState   uint
+		Reason     string
+		Start, End time.Time
+		Duration   time.Duration
+	}
+)
+
+// appHandler shows the details and the state transitions of a single app by its vistecture or kubernetes name
+func (d *DashboardController) appHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes, store history.Store) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	deployment, ok := findApp(statusFetcher.GetCurrentResult(), r.PathValue("name"))
+	if ok && current != nil {
+		ok = slices.Contains(current.Applications, deployment.VistectureApp.Name)
+	}
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	now := time.Now()
+	since := now.Add(-defaultTimelinePeriod)
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = parseHistoryTime(value, now); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	viewdata := appData{
+		layoutData:     newLayoutData(r, deployment.VistectureApp.Name, envs, statusFetcher, scopes, current),
+		App:            deployment,
+		Spec:           newWorkloadSpec(deployment),
+		HistoryEnabled: store != nil,
+		Since:          since,
+	}
+	if store != nil {
+		records := store.Query(history.Query{Environment: statusFetcher.Environment(), Application: deployment.VistectureApp.Name})
+		viewdata.Timeline = buildTimeline(records, since, now)
+	}
+
+	d.render(rw, "app", viewdata)
+}
+
+// buildTimeline turns the records of an app into the periods of its states since the given time, the newest first.
+// The last record before since is the state at the beginning of the timeline.
+func buildTimeline(records []history.Record, since, now time.Time) []transition {
+	var timeline []transition
+	for i, record := range records {
+		end := time.Time{}
+		if i+1 < len(records) {
+			end = records[i+1].Time
+			if !end.After(since) {
+				continue
+			}
+		}
+
+		start := record.Time
+		if start.Before(since) {
+			start = since
+		}
+
+		state, _ := kube.StateByName(record.State)
+		ownState, _ := kube.StateByName(record.OwnState)
+		t := transition{State: state, OwnState: ownState, Reason: record.Reason, Start: start, End: end}
+		if end.IsZero() {
+			t.Duration = now.Sub(start)
+		} else {
+			t.Duration = end.Sub(start)
+		}
+		timeline = append(timeline, t)
+	}
+
+	slices.Reverse(timeline)
+	return timeline
+}
+
+// newWorkloadSpec summarizes the spec of the checked workload, nil for jobs and apps without workload
+func newWorkloadSpec(deployment kube.AppDeploymentInfo) *workloadSpec {
+	var spec workloadSpec
+	var template v1.PodTemplateSpec
+	var selector map[string]string
+
+	switch deployment.K8sType {
+	case kube.K8sType_Deployment:
+		if deployment.K8sDeployment.Name == "" {
+			return nil
+		}
+		spec.Kind = "Deployment"
+		spec.Strategy = string(deployment.K8sDeployment.Spec.Strategy.Type)
+		template = deployment.K8sDeployment.Spec.Template
+		if s := deployment.K8sDeployment.Spec.Selector; s != nil {
+			selector = s.MatchLabels
+		}
+	case kube.K8sType_StatefulSet:
+		if deployment.K8sStatefulSet.Name == "" {
+			return nil
+		}
+		spec.Kind = "StatefulSet"
+		spec.Strategy = string(deployment.K8sStatefulSet.Spec.UpdateStrategy.Type)
+		template = deployment.K8sStatefulSet.Spec.Template
+		if s := deployment.K8sStatefulSet.Spec.Selector; s != nil {
+			selector = s.MatchLabels
+		}
+	case kube.K8sType_DaemonSet:
+		if deployment.K8sDaemonSet.Name == "" {
+			return nil
+		}
+		spec.Kind = "DaemonSet"
+		spec.Strategy = string(deployment.K8sDaemonSet.Spec.UpdateStrategy.Type)
+		template = deployment.K8sDaemonSet.Spec.Template
+		if s := deployment.K8sDaemonSet.Spec.Selector; s != nil {
+			selector = s.MatchLabels
+		}
+	default:
+		return nil
+	}
+
+	spec.Selector = formatLabels(selector)
+	spec.ServiceAccount = template.Spec.ServiceAccountName
+	for _, container := range template.Spec.Containers {
+		c := containerSpec{
+			Name:     container.Name,
+			Image:    container.Image,
+			Requests: formatResources(container.Resources.Requests),
+			Limits:   formatResources(container.Resources.Limits),
+		}
+		for _, port := range container.Ports {
+			p := fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol)
+			if port.Name != "" {
+				p = port.Name + " " + p
+			}
+			c.Ports = append(c.Ports, p)
+		}
+		for _, probe := range []struct {
+			name  string
+			probe *v1.Probe
+		}{{"liveness", container.LivenessProbe}, {"readiness", container.ReadinessProbe}, {"startup", container.StartupProbe}} {
+			if probe.probe != nil {
+				c.Probes = append(c.Probes, probe.name+": "+formatProbe(probe.probe))
+			}
+		}
+		spec.Containers = append(spec.Containers, c)
+	}
+
+	return &spec
+}
+
+// formatLabels returns the labels as sorted key=value list
+func formatLabels(labels map[string]string) string {
+	list := make([]string, 0, len(labels))
+	for key, value := range labels {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+
+	return strings.Join(list, ", ")
+}
+
+// formatResources returns the resources as sorted list, e.g. "cpu=100m, memory=128Mi"
+func formatResources(resources v1.ResourceList) string {
+	list := make([]string, 0, len(resources))
+	for name, quantity := range resources {
+		list = append(list, string(name)+"="+quantity.String())
+	}
+	sort.Strings(list)
+
+	return strings.Join(list, ", ")
+}
+
+// formatProbe describes the handler of a probe
+func formatProbe(probe *v1.Probe) string {
+	switch {
+	case probe.HTTPGet != nil:
+		return fmt.Sprintf("GET :%s%s", probe.HTTPGet.Port.String(), probe.HTTPGet.Path)
+	case probe.TCPSocket != nil:
+		return "TCP :" + probe.TCPSocket.Port.String()
+	case probe.GRPC != nil:
+		return fmt.Sprintf("gRPC :%d", probe.GRPC.Port)
+	case probe.Exec != nil:
+		return "exec " + strings.Join(probe.Exec.Command, " ")
+	}
+
+	return "unknown"
+}
@@ -0,0 +1,63 @@
+package interfaces
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestBuildTimeline(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-24 * time.Hour)
+	records := []history.Record{
+		{Time: now.Add(-72 * time.Hour), State: "healthy"},
+		{Time: now.Add(-48 * time.Hour), State: "failed", Reason: "No pod available"},
+		{Time: now.Add(-2 * time.Hour), State: "impacted", OwnState: "unhealthy", Reason: "Service Unhealthy"},
+		{Time: now.Add(-30 * time.Minute), State: "healthy"},
+	}
+
+	expected := []transition{
+		{State: kube.State_healthy, Start: now.Add(-30 * time.Minute), Duration: 30 * time.Minute},
+		{State: kube.State_impacted, OwnState: kube.State_unhealthy, Reason: "Service Unhealthy", Start: now.Add(-2 * time.Hour), End: now.Add(-30 * time.Minute), Duration: 90 * time.Minute},
+		{State: kube.State_failed, Reason: "No pod available", Start: since, End: now.Add(-2 * time.Hour), Duration: 22 * time.Hour},
+	}
+	if timeline := buildTimeline(records, since, now); !reflect.DeepEqual(timeline, expected) {
+		t.Errorf("expected %+v, got %+v", expected, timeline)
+	}
+
+	if timeline := buildTimeline(nil, since, now); len(timeline) != 0 {
+		t.Errorf("expected an empty timeline without records, got %+v", timeline)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for duration, expected := range map[time.Duration]string{
+		42 * time.Second:                "42s",
+		3*time.Minute + 20*time.Second:  "3m20s",
+		2*time.Hour + 15*time.Minute:    "2h15m",
+		76*time.Hour + 30*time.Minute:   "3d4h",
+		time.Hour + 10*time.Millisecond: "1h0m",
+	} {
+		if formatted := formatDuration(duration); formatted != expected {
+			t.Errorf("expected %v to be formatted as %q, got %q", duration, expected, formatted)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if svg := sparkline([]time.Duration{time.Second}); svg != "" {
+		t.Errorf("expected no sparkline for a single check, got %v", svg)
+	}
+
+	svg := string(sparkline([]time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 50 * time.Millisecond}))
+	if !strings.Contains(svg, `points="0.0,12.0 60.0,1.0 120.0,17.5"`) {
+		t.Errorf("unexpected points in %v", svg)
+	}
+	if !strings.Contains(svg, "last 3 checks, 50ms to 200ms") {
+		t.Errorf("unexpected title in %v", svg)
+	}
+}
@@ -0,0 +1,175 @@
+package interfaces
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// availabilityData is the availability report of an environment
+	availabilityData struct {
+		layoutData
+		HistoryEnabled bool
+		Windows        []kube.AvailabilityWindow
+		Teams, Apps    []kube.AvailabilityReport
+	}
+
+	// availabilityTable is a table of the availability page, of apps or teams
+	availabilityTable struct {
+		Page    availabilityData
+		Reports []kube.AvailabilityReport
+		Apps    bool
+	}
+
+	// apiAvailabilityReport is the JSON representation of the availability reports of an environment
+	apiAvailabilityReport struct {
+		Environment  string                 `json:"environment"`
+		GeneratedAt  time.Time              `json:"generatedAt"`
+		Applications []apiAvailabilityEntry `json:"applications"`
+		Teams        []apiAvailabilityEntry `json:"teams"`
+	}
+
+	apiAvailabilityEntry struct {
+		Name    string                     `json:"name"`
+		Team    string                     `json:"team,omitempty"`
+		Windows map[string]apiAvailability `json:"windows"`
+	}
+
+	apiAvailability struct {
+		// Percentage is null if no check result was recorded in the window
+		Percentage      *float64 `json:"percentage"`
+		Incidents       int      `json:"incidents"`
+		MTTRSeconds     int      `json:"mttrSeconds"`
+		MeasuredSeconds int      `json:"measuredSeconds"`
+		DownSeconds     int      `json:"downSeconds"`
+	}
+)
+
+// availabilityHandler shows the availability of all apps and teams of the environment given in the query (or the default)
+func (d *DashboardController) availabilityHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	apps := statusFetcher.AvailabilityReports(time.Now())
+	viewdata := availabilityData{
+		layoutData:     newLayoutData(r, "Availability", envs, statusFetcher, scopes, nil),
+		HistoryEnabled: statusFetcher.History != nil,
+		Windows:        kube.AvailabilityWindows,
+		Teams:          kube.TeamAvailabilityReports(apps),
+		Apps:           apps,
+	}
+
+	d.render(rw, "availability", viewdata)
+}
+
+// apiAvailabilityHandler returns the availability of all apps and teams of the environment given in the query (or the default)
+func (d *DashboardController) apiAvailabilityHandler(rw http.ResponseWriter, r *http.Request, envs environments) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "environment " + r.URL.Query().Get("environment") + " not found"})
+		return
+	}
+	if statusFetcher.History == nil {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "history is not enabled, see -history-file"})
+		return
+	}
+
+	now := time.Now()
+	apps := statusFetcher.AvailabilityReports(now)
+	report := apiAvailabilityReport{
+		Environment:  statusFetcher.Environment(),
+		GeneratedAt:  now,
+		Applications: toApiAvailabilityEntries(apps, true),
+		Teams:        toApiAvailabilityEntries(kube.TeamAvailabilityReports(apps), false),
+	}
+
+	writeJSON(rw, http.StatusOK, report)
+}
+
+// availabilityCSVHandler exports the availability of all teams and apps as CSV, with the percentage, incidents and MTTR per window
+func (d *DashboardController) availabilityCSVHandler(rw http.ResponseWriter, r *http.Request, envs environments) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	if statusFetcher.History == nil {
+		http.Error(rw, "history is not enabled, see -history-file", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	apps := statusFetcher.AvailabilityReports(now)
+
+	rw.Header().Set("content-type", "text/csv")
+	rw.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=\"availability-%s-%s.csv\"", statusFetcher.Environment(), now.Format("2006-01-02")))
+	_ = writeAvailabilityCSV(csv.NewWriter(rw), kube.TeamAvailabilityReports(apps), apps)
+}
+
+// writeAvailabilityCSV writes a line per team and app, the availability of unknown windows is left empty
+func writeAvailabilityCSV(w *csv.Writer, teams, apps []kube.AvailabilityReport) error {
+	header := []string{"type", "name", "team"}
+	for _, window := range kube.AvailabilityWindows {
+		header = append(header, "availability_"+window.Name, "incidents_"+window.Name, "mttr_minutes_"+window.Name)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, list := range []struct {
+		kind    string
+		reports []kube.AvailabilityReport
+	}{{"team", teams}, {"application", apps}} {
+		for _, report := range list.reports {
+			line := []string{list.kind, report.Name, report.Team}
+			for _, availability := range report.Windows {
+				percentage := ""
+				if availability.Known() {
+					percentage = strconv.FormatFloat(availability.Percentage(), 'f', 3, 64)
+				}
+				line = append(line, percentage, strconv.Itoa(availability.Incidents), strconv.FormatFloat(availability.MTTR().Minutes(), 'f', 1, 64))
+			}
+			if err := w.Write(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// toApiAvailabilityEntries converts the reports of apps (withTeam) or teams
+func toApiAvailabilityEntries(reports []kube.AvailabilityReport, withTeam bool) []apiAvailabilityEntry {
+	entries := make([]apiAvailabilityEntry, 0, len(reports))
+	for _, report := range reports {
+		entry := apiAvailabilityEntry{Name: report.Name, Windows: make(map[string]apiAvailability, len(report.Windows))}
+		if withTeam {
+			entry.Team = report.Team
+		}
+		for _, availability := range report.Windows {
+			api := apiAvailability{
+				Incidents:       availability.Incidents,
+				MTTRSeconds:     int(availability.MTTR().Seconds()),
+				MeasuredSeconds: int(availability.Measured.Seconds()),
+				DownSeconds:     int(availability.Down.Seconds()),
+			}
+			if availability.Known() {
+				percentage := availability.Percentage()
+				api.Percentage = &percentage
+			}
+			entry.Windows[availability.Window.Name] = api
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
@@ -0,0 +1,38 @@
+package interfaces
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestWriteAvailabilityCSV(t *testing.T) {
+	windows := func(availability kube.Availability) []kube.Availability {
+		var list []kube.Availability
+		for _, window := range kube.AvailabilityWindows {
+			availability.Window = window
+			list = append(list, availability)
+		}
+		return list
+	}
+	apps := []kube.AvailabilityReport{
+		{Name: "flamingo", Team: "Team 1", Windows: windows(kube.Availability{Measured: 100 * time.Minute, Down: 10 * time.Minute, Incidents: 2})},
+		{Name: "akeneo", Windows: windows(kube.Availability{})},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeAvailabilityCSV(csv.NewWriter(buf), kube.TeamAvailabilityReports(apps), apps); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "type,name,team,availability_24h,incidents_24h,mttr_minutes_24h,availability_7d,incidents_7d,mttr_minutes_7d,availability_30d,incidents_30d,mttr_minutes_30d\n" +
+		"team,Team 1,Team 1,90.000,2,5.0,90.000,2,5.0,90.000,2,5.0\n" +
+		"application,flamingo,Team 1,90.000,2,5.0,90.000,2,5.0,90.000,2,5.0\n" +
+		"application,akeneo,,,0,0.0,,0,0.0,,0,0.0\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,53 @@
+package interfaces
+
+import (
+	"net/http"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// Environment is a kubernetes context (e.g. a cluster per stage) checked by the dashboard
+	Environment struct {
+		// Name is shown in the dashboard and used as label, e.g. "stage"
+		Name string
+		// KubeContext of the kubeconfig, the current context if empty
+		KubeContext string
+	}
+
+	// environments holds a status fetcher per environment, the first one is the default
+	environments []*kube.StatusFetcher
+)
+
+// defaultEnvironment is used if no environment is configured
+const defaultEnvironment = "default"
+
+// get returns the status fetcher of the named environment, an empty name returns the default
+func (envs environments) get(name string) (*kube.StatusFetcher, bool) {
+	if name == "" {
+		return envs[0], true
+	}
+
+	for _, statusFetcher := range envs {
+		if statusFetcher.Environment() == name {
+			return statusFetcher, true
+		}
+	}
+
+	return nil, false
+}
+
+// fromRequest returns the status fetcher of the environment given in the query
+func (envs environments) fromRequest(r *http.Request) (*kube.StatusFetcher, bool) {
+	return envs.get(r.URL.Query().Get("environment"))
+}
+
+// names returns the names of all environments
+func (envs environments) names() []string {
+	names := make([]string, 0, len(envs))
+	for _, statusFetcher := range envs {
+		names = append(names, statusFetcher.Environment())
+	}
+
+	return names
+}
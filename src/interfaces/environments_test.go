@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestEnvironments_FromRequest(t *testing.T) {
+	envs := environments{
+		kube.NewStatusFetcher("stage", nil, kube.NewDemoService(0)),
+		kube.NewStatusFetcher("prod", nil, kube.NewDemoService(0)),
+	}
+
+	testCases := []struct {
+		url, expected string
+		found         bool
+	}{
+		{url: "/", expected: "stage", found: true},
+		{url: "/?environment=prod", expected: "prod", found: true},
+		{url: "/?environment=dev", found: false},
+	}
+
+	for _, tc := range testCases {
+		statusFetcher, found := envs.fromRequest(httptest.NewRequest("GET", tc.url, nil))
+		if found != tc.found {
+			t.Errorf("%s: expected found %v, got %v", tc.url, tc.found, found)
+			continue
+		}
+		if found && statusFetcher.Environment() != tc.expected {
+			t.Errorf("%s: expected environment %q, got %q", tc.url, tc.expected, statusFetcher.Environment())
+		}
+	}
+}
+
+func TestRelativeRoot(t *testing.T) {
+	for url, expected := range map[string]string{
+		"/":              "./",
+		"/matrix":        "./",
+		"/team/frontend": "../",
+	} {
+		if root := relativeRoot(httptest.NewRequest("GET", url, nil)); root != expected {
+			t.Errorf("%s: expected %q, got %q", url, expected, root)
+		}
+	}
+}
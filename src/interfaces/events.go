@@ -0,0 +1,132 @@
+package interfaces
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// eventApp is an app sent via server sent events, containing the rendered table row
+	eventApp struct {
+		apiApp
+		HTML string `json:"html"`
+	}
+
+	eventSnapshot struct {
+		Time time.Time  `json:"time"`
+		Apps []eventApp `json:"apps"`
+	}
+
+	eventCycle struct {
+		Time  time.Time      `json:"time"`
+		Fetch apiFetchStatus `json:"fetch"`
+	}
+)
+
+// keepAliveInterval is the interval for comments sent to keep idle connections open
+const keepAliveInterval = 30 * time.Second
+
+// eventsHandler streams the app states (of the scope) as server sent events:
+// a "snapshot" with all apps on connect, a "delta" for each changed app and a "cycle" after each fetch cycle (also failed ones).
+// The stream ends if the client does not keep up with the updates, the EventSource reconnects and receives a new snapshot.
+func (d *DashboardController) eventsHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	filter := appFilter{Applications: current.applications()}
+
+	tpl, err := d.loadTemplate("dashboard")
+	if err != nil {
+		e(rw, err)
+		return
+	}
+
+	// subscribe before taking the snapshot to not miss updates in between
+	updates, unsubscribe := statusFetcher.Subscribe()
+	defer unsubscribe()
+
+	rw.Header().Set("content-type", "text/event-stream")
+	rw.Header().Set("cache-control", "no-cache")
+	rw.Header().Set("connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(rw)
+
+	snapshot := eventSnapshot{Time: time.Now(), Apps: []eventApp{}}
+	fetchStatus := statusFetcher.GetFetchStatus()
+	for _, deployment := range filter.apply(statusFetcher.GetCurrentResult()) {
+		snapshot.Apps = append(snapshot.Apps, toEventApp(tpl, deployment, fetchStatus))
+	}
+	if writeEvent(rw, "snapshot", snapshot) != nil || controller.Flush() != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(rw, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			for _, deployment := range update.Changed {
+				if !filter.matches(deployment) {
+					continue
+				}
+				if writeEvent(rw, "delta", toEventApp(tpl, deployment, update.FetchStatus)) != nil {
+					return
+				}
+			}
+			if update.Cycle && writeEvent(rw, "cycle", eventCycle{Time: update.Time, Fetch: toApiFetchStatus(update.FetchStatus)}) != nil {
+				return
+			}
+		}
+
+		if controller.Flush() != nil {
+			return
+		}
+	}
+}
+
+// toEventApp renders the table row of the deployment
+func toEventApp(tpl *template.Template, deployment kube.AppDeploymentInfo, fetchStatus kube.FetchStatus) eventApp {
+	buf := new(bytes.Buffer)
+	if err := tpl.ExecuteTemplate(buf, "row", deployment); err != nil {
+		log.Printf("Could not render row for %v: %v", deployment.Name, err)
+		buf.Reset()
+	}
+
+	return eventApp{apiApp: toApiApp(deployment, fetchStatus), HTML: buf.String()}
+}
+
+// writeEvent writes a single server sent event with JSON encoded data
+func writeEvent(rw http.ResponseWriter, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
@@ -0,0 +1,110 @@
+package interfaces
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+// readEvent reads the next server sent event, comments are skipped
+func readEvent(t *testing.T, reader *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the event failed: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	apps := []*vistectureCore.Application{
+		{Name: "flamingo", Team: "Team 1", Properties: map[string]string{"deployment": "kubernetes"}},
+		{Name: "akeneo", Team: "Team 2", Properties: map[string]string{"deployment": "kubernetes"}},
+	}
+	statusFetcher := kube.NewStatusFetcher("prod", apps, kube.NewDemoService(0))
+	envs := environments{statusFetcher}
+	scopes := newPageScopes(nil, apps)
+	d := &DashboardController{Templates: "../../templates/dashboard"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /team/{team}/events", func(w http.ResponseWriter, r *http.Request) {
+		d.eventsHandler(w, r, envs, scopes)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	connect := func() (*bufio.Reader, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		request, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/team/Team%201/events", nil)
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+			t.Errorf("expected an event stream, got %q", contentType)
+		}
+		return bufio.NewReader(response.Body), cancel
+	}
+
+	reader, cancel := connect()
+	if event, data := readEvent(t, reader); event != "snapshot" || !strings.Contains(data, `"apps":[]`) {
+		t.Fatalf("expected an empty snapshot before the first check, got %v %v", event, data)
+	}
+
+	// the apps are checked once, akeneo is not in the scope of team 1
+	go statusFetcher.FetchStatusInRegularInterval(nil)
+
+	var deltas []string
+	for {
+		event, data := readEvent(t, reader)
+		if event == "cycle" {
+			break
+		}
+		if event != "delta" {
+			t.Fatalf("unexpected event %v %v", event, data)
+		}
+		var app eventApp
+		if err := json.Unmarshal([]byte(data), &app); err != nil {
+			t.Fatal(err)
+		}
+		if app.HTML == "" {
+			t.Errorf("expected the rendered row of %v", app.ID)
+		}
+		deltas = append(deltas, app.ID)
+	}
+	cancel()
+	if len(deltas) != 1 || deltas[0] != "flamingo" {
+		t.Errorf("expected a delta of flamingo only, got %v", deltas)
+	}
+
+	reader, cancel = connect()
+	defer cancel()
+	_, data := readEvent(t, reader)
+	var snapshot eventSnapshot
+	if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Apps) != 1 || snapshot.Apps[0].ID != "flamingo" {
+		t.Errorf("expected the snapshot of the scope after the check, got %+v", snapshot.Apps)
+	}
+}
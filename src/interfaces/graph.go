@@ -0,0 +1,357 @@
+package interfaces
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// graphData is the dependency graph page of an environment
+	graphData struct {
+		layoutData
+		Graph appGraph
+		// Query is passed to the svg and dot links, to keep the environment
+		Query string
+	}
+
+	// appGraph is the laid out dependency graph of the apps, dependencies are placed below the apps using them
+	appGraph struct {
+		Name string
+		// Root and Environment are used for the links of the nodes to the app pages (of the scope)
+		Root, Environment string
+		Nodes             []graphNode
+		Edges             []graphEdge
+		Width, Height     int
+	}
+
+	graphNode struct {
+		Name, Title, Team string
+		// Checked is false if the environment has no result for the app (yet)
+		Checked     bool
+		State       uint
+		StateReason string
+		RootCause   bool
+		X, Y        int
+	}
+
+	// graphEdge points from an app to its dependency
+	graphEdge struct {
+		From, To     string
+		Relationship string
+		// Broken is set if the dependency is failed or unhealthy, Impacted if it is impacted by another one
+		Broken, Impacted bool
+		X1, Y1, X2, Y2   int
+	}
+)
+
+const (
+	graphNodeWidth  = 170
+	graphNodeHeight = 46
+	graphGapX       = 30
+	graphGapY       = 70
+	graphMargin     = 20
+	graphLabelChars = 22
+)
+
+// graphStateColors are the fill colors of the nodes, matching the colors of the state icons
+var graphStateColors = map[uint]string{
+	kube.State_failed:    "#ef9a9a",
+	kube.State_unhealthy: "#ffcdd2",
+	kube.State_impacted:  "#ffccbc",
+	kube.State_degraded:  "#ffe0b2",
+	kube.State_unstable:  "#fff9c4",
+	kube.State_healthy:   "#c8e6c9",
+	kube.State_ignored:   "#d7ccc8",
+	kube.State_unknown:   "#cfd8dc",
+}
+
+// graphHandler shows the dependency graph of the environment as page (format empty), "svg" or "dot"
+func (d *DashboardController) graphHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes, project *vistectureCore.Project, format string) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	name := project.Name
+	if current != nil {
+		name = current.Name
+	}
+
+	graph := buildAppGraph(name, project.Applications, statusFetcher.GetCurrentResult(), current.applications())
+	graph.Root = viewRoot(r, current)
+	graph.Environment = statusFetcher.Environment()
+
+	switch format {
+	case "dot":
+		rw.Header().Set("content-type", "text/vnd.graphviz; charset=utf-8")
+		_ = writeDot(rw, graph)
+	case "svg":
+		d.renderTemplate(rw, "graph", "graphSvg", "image/svg+xml", graph)
+	default:
+		viewdata := graphData{
+			layoutData: newLayoutData(r, "Dependencies", envs, statusFetcher, scopes, current),
+			Graph:      graph,
+			Query:      r.URL.RawQuery,
+		}
+		viewdata.LiveUpdates = true
+		if viewdata.Query != "" {
+			viewdata.Query = "?" + viewdata.Query
+		}
+
+		d.render(rw, "graph", viewdata)
+	}
+}
+
+// buildAppGraph creates the graph of the apps with their current state, restricted to the included apps if given.
+// Dependencies to apps that are not part of the graph are left out.
+func buildAppGraph(name string, apps []*vistectureCore.Application, result map[string]kube.AppDeploymentInfo, included []string) appGraph {
+	graph := appGraph{Name: name}
+	index := make(map[string]int)
+	for _, app := range apps {
+		if included != nil && !slices.Contains(included, app.Name) {
+			continue
+		}
+
+		deployment, checked := result[app.Name]
+		index[app.Name] = len(graph.Nodes)
+		graph.Nodes = append(graph.Nodes, graphNode{
+			Name:        app.Name,
+			Title:       app.Title,
+			Team:        app.Team,
+			Checked:     checked,
+			State:       deployment.AppStateInfo.State,
+			StateReason: deployment.AppStateInfo.StateReason,
+			RootCause:   len(deployment.AppStateInfo.Impacts) > 0,
+		})
+	}
+
+	dependencies := make(map[string][]string)
+	for _, app := range apps {
+		if _, ok := index[app.Name]; !ok {
+			continue
+		}
+		for _, dependency := range app.Dependencies {
+			to, _, _ := strings.Cut(dependency.Reference, ".")
+			i, ok := index[to]
+			if !ok || to == app.Name || slices.Contains(dependencies[app.Name], to) {
+				continue
+			}
+
+			dependencies[app.Name] = append(dependencies[app.Name], to)
+			target := graph.Nodes[i]
+			graph.Edges = append(graph.Edges, graphEdge{
+				From:         app.Name,
+				To:           to,
+				Relationship: dependency.Relationship,
+				Broken:       target.Checked && kube.IsBroken(target.State),
+				Impacted:     target.Checked && target.State == kube.State_impacted,
+			})
+		}
+	}
+
+	graph.layout(index, dependencies)
+
+	return graph
+}
+
+// layout places every node in the layer of its longest dependency chain, apps without dependencies at the bottom.
+// Within a layer the nodes are ordered by the position of the apps using them to reduce crossing edges.
+func (g *appGraph) layout(index map[string]int, dependencies map[string][]string) {
+	heights := make(map[string]int)
+	visiting := make(map[string]bool)
+	var height func(name string) int
+	height = func(name string) int {
+		if h, ok := heights[name]; ok {
+			return h
+		}
+		// dependency cycles are cut where they are found
+		visiting[name] = true
+		h := 0
+		for _, dependency := range dependencies[name] {
+			if !visiting[dependency] {
+				h = max(h, height(dependency)+1)
+			}
+		}
+		visiting[name] = false
+		heights[name] = h
+		return h
+	}
+
+	maxHeight := 0
+	for i := range g.Nodes {
+		maxHeight = max(maxHeight, height(g.Nodes[i].Name))
+	}
+
+	layers := make([][]int, maxHeight+1)
+	for i := range g.Nodes {
+		layer := maxHeight - heights[g.Nodes[i].Name]
+		layers[layer] = append(layers[layer], i)
+	}
+
+	position := make(map[string]float64)
+	widest := 0
+	for _, layer := range layers {
+		center := make(map[int]float64, len(layer))
+		for _, i := range layer {
+			var sum float64
+			var count int
+			for user, userDependencies := range dependencies {
+				if p, ok := position[user]; ok && slices.Contains(userDependencies, g.Nodes[i].Name) {
+					sum += p
+					count++
+				}
+			}
+			// nodes without placed users go to the end of the layer
+			center[i] = float64(len(g.Nodes))
+			if count > 0 {
+				center[i] = sum / float64(count)
+			}
+		}
+		sort.SliceStable(layer, func(a, b int) bool {
+			if center[layer[a]] != center[layer[b]] {
+				return center[layer[a]] < center[layer[b]]
+			}
+			return g.Nodes[layer[a]].Name < g.Nodes[layer[b]].Name
+		})
+		for p, i := range layer {
+			position[g.Nodes[i].Name] = float64(p)
+		}
+		widest = max(widest, len(layer))
+	}
+
+	g.Width = 2*graphMargin + widest*(graphNodeWidth+graphGapX) - graphGapX
+	g.Height = 2*graphMargin + len(layers)*(graphNodeHeight+graphGapY) - graphGapY
+	if len(g.Nodes) == 0 {
+		g.Width, g.Height = 2*graphMargin, 2*graphMargin
+	}
+
+	for l, layer := range layers {
+		offset := (g.Width - len(layer)*(graphNodeWidth+graphGapX) + graphGapX) / 2
+		for p, i := range layer {
+			g.Nodes[i].X = offset + p*(graphNodeWidth+graphGapX)
+			g.Nodes[i].Y = graphMargin + l*(graphNodeHeight+graphGapY)
+		}
+	}
+
+	for i, edge := range g.Edges {
+		from, to := g.Nodes[index[edge.From]], g.Nodes[index[edge.To]]
+		g.Edges[i].X1, g.Edges[i].Y1 = from.X+graphNodeWidth/2, from.Y+graphNodeHeight
+		g.Edges[i].X2, g.Edges[i].Y2 = to.X+graphNodeWidth/2, to.Y
+	}
+}
+
+// NodeWidth is the width of all nodes
+func (g appGraph) NodeWidth() int {
+	return graphNodeWidth
+}
+
+// NodeHeight is the height of all nodes
+func (g appGraph) NodeHeight() int {
+	return graphNodeHeight
+}
+
+// CenterX is the horizontal center of the node, used for its labels
+func (n graphNode) CenterX() int {
+	return n.X + graphNodeWidth/2
+}
+
+// Color is the fill color of the node by its state
+func (n graphNode) Color() string {
+	if !n.Checked {
+		return "#eeeeee"
+	}
+	return graphStateColors[n.State]
+}
+
+// Label is the name shortened to fit into the node
+func (n graphNode) Label() string {
+	name := []rune(n.Name)
+	if len(name) <= graphLabelChars {
+		return n.Name
+	}
+	return string(name[:graphLabelChars-1]) + "…"
+}
+
+// Tooltip describes the node with its state and the reason
+func (n graphNode) Tooltip() string {
+	if !n.Checked {
+		return n.Name + ": not checked"
+	}
+
+	tooltip := n.Name + ": " + kube.StateName(n.State)
+	if n.StateReason != "" {
+		tooltip += "\n" + n.StateReason
+	}
+	return tooltip
+}
+
+// Color is the stroke color of the edge, highlighting broken and impacted dependencies
+func (e graphEdge) Color() string {
+	switch {
+	case e.Broken:
+		return "#c62828"
+	case e.Impacted:
+		return "#ff7043"
+	}
+	return "#90a4ae"
+}
+
+// Path is the svg path of the edge as curve from the bottom of the app to the top of the dependency
+func (e graphEdge) Path() string {
+	return fmt.Sprintf("M %d %d C %d %d, %d %d, %d %d", e.X1, e.Y1, e.X1, e.Y1+graphGapY/2, e.X2, e.Y2-graphGapY/2, e.X2, e.Y2)
+}
+
+// writeDot writes the graph in the graphviz DOT format, nodes are filled by state like in the svg
+func writeDot(w io.Writer, graph appGraph) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(graph.Name))
+	b.WriteString("  rankdir=TB;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=10];\n")
+
+	for _, node := range graph.Nodes {
+		label := node.Name
+		if node.Team != "" {
+			label += "\n" + node.Team
+		}
+		attributes := fmt.Sprintf("label=%s, fillcolor=%s, tooltip=%s", dotQuote(label), dotQuote(node.Color()), dotQuote(node.Tooltip()))
+		if node.RootCause {
+			attributes += ", penwidth=3, color=\"#c62828\""
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.Name), attributes)
+	}
+
+	for _, edge := range graph.Edges {
+		attributes := "color=" + dotQuote(edge.Color())
+		if edge.Broken || edge.Impacted {
+			attributes += ", penwidth=2"
+		}
+		if edge.Relationship != "" {
+			attributes += ", label=" + dotQuote(edge.Relationship)
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), attributes)
+	}
+
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote returns s as quoted DOT ID
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
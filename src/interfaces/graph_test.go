@@ -0,0 +1,95 @@
+package interfaces
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestBuildAppGraph(t *testing.T) {
+	apps := []*vistectureCore.Application{
+		{Name: "shop", Dependencies: []vistectureCore.DependencyReference{{Reference: "flamingo"}}},
+		{Name: "flamingo", Team: "Team 1", Dependencies: []vistectureCore.DependencyReference{{Reference: "akeneo.api"}, {Reference: "akeneo.export"}, {Reference: "external"}}},
+		{Name: "akeneo"},
+		{Name: "keycloak"},
+	}
+	result := map[string]kube.AppDeploymentInfo{
+		"shop":     {AppStateInfo: kube.AppStateInfo{State: kube.State_impacted}},
+		"flamingo": {AppStateInfo: kube.AppStateInfo{State: kube.State_impacted}},
+		"akeneo":   {AppStateInfo: kube.AppStateInfo{State: kube.State_failed, Impacts: []string{"flamingo", "shop"}}},
+	}
+
+	graph := buildAppGraph("Demoproject", apps, result, nil)
+
+	if len(graph.Nodes) != 4 {
+		t.Fatalf("expected 4 nodes, got %d", len(graph.Nodes))
+	}
+	if len(graph.Edges) != 2 {
+		t.Fatalf("expected the edges shop -> flamingo and flamingo -> akeneo, got %+v", graph.Edges)
+	}
+	if edge := graph.Edges[0]; edge.From != "shop" || edge.To != "flamingo" || !edge.Impacted || edge.Broken {
+		t.Errorf("expected an impacted edge from shop to flamingo, got %+v", edge)
+	}
+	if edge := graph.Edges[1]; edge.From != "flamingo" || edge.To != "akeneo" || !edge.Broken {
+		t.Errorf("expected a broken edge from flamingo to akeneo, got %+v", edge)
+	}
+
+	y := make(map[string]int)
+	for _, node := range graph.Nodes {
+		y[node.Name] = node.Y
+	}
+	if !(y["shop"] < y["flamingo"] && y["flamingo"] < y["akeneo"] && y["akeneo"] == y["keycloak"]) {
+		t.Errorf("expected dependencies below the apps using them and apps without dependencies at the bottom, got %v", y)
+	}
+	if graph.Nodes[3].Checked || !graph.Nodes[2].RootCause {
+		t.Errorf("expected keycloak to be unchecked and akeneo to be a root cause, got %+v", graph.Nodes)
+	}
+
+	subGraph := buildAppGraph("minimal", apps, result, []string{"flamingo", "akeneo"})
+	if len(subGraph.Nodes) != 2 || len(subGraph.Edges) != 1 || subGraph.Edges[0].From != "flamingo" {
+		t.Errorf("expected only flamingo -> akeneo in the sub view, got %+v", subGraph)
+	}
+}
+
+func TestWriteDot(t *testing.T) {
+	apps := []*vistectureCore.Application{
+		{Name: "flamingo", Team: "Team \"1\"", Dependencies: []vistectureCore.DependencyReference{{Reference: "akeneo", Relationship: "uses"}}},
+		{Name: "akeneo"},
+	}
+	result := map[string]kube.AppDeploymentInfo{
+		"flamingo": {AppStateInfo: kube.AppStateInfo{State: kube.State_healthy}},
+		"akeneo":   {AppStateInfo: kube.AppStateInfo{State: kube.State_unhealthy}},
+	}
+
+	var b strings.Builder
+	if err := writeDot(&b, buildAppGraph("Demo", apps, result, nil)); err != nil {
+		t.Fatal(err)
+	}
+	dot := b.String()
+
+	for _, expected := range []string{
+		`digraph "Demo" {`,
+		`"flamingo" [label="flamingo\nTeam \"1\"", fillcolor="#c8e6c9"`,
+		`"akeneo" [label="akeneo", fillcolor="#ffcdd2"`,
+		`"flamingo" -> "akeneo" [color="#c62828", penwidth=2, label="uses"];`,
+	} {
+		if !strings.Contains(dot, expected) {
+			t.Errorf("expected %q in\n%s", expected, dot)
+		}
+	}
+}
+
+func TestGraphNode_Label(t *testing.T) {
+	if label := (graphNode{Name: "flamingo"}).Label(); label != "flamingo" {
+		t.Errorf("expected the short name unchanged, got %q", label)
+	}
+
+	label := graphNode{Name: "zahlungsabwicklung-räder-öde"}.Label()
+	if label != "zahlungsabwicklung-rä…" || !utf8.ValidString(label) {
+		t.Errorf("expected the name to be shortened to %d runes, got %q", graphLabelChars, label)
+	}
+}
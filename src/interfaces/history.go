@@ -0,0 +1,91 @@
+package interfaces
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+)
+
+// defaultHistoryPeriod is the period returned by the history api without since parameter
+const defaultHistoryPeriod = 24 * time.Hour
+
+// apiHistoryHandler returns the recorded state changes of the environment given in the query (or the default),
+// optionally of a single application and for the period given by since and until (RFC3339 or a duration like 12h before now)
+func (d *DashboardController) apiHistoryHandler(rw http.ResponseWriter, r *http.Request, envs environments, store history.Store) {
+	query, ok := historyQuery(rw, r, envs, store)
+	if !ok {
+		return
+	}
+
+	records := store.Query(query)
+	if records == nil {
+		records = []history.Record{}
+	}
+	writeJSON(rw, http.StatusOK, records)
+}
+
+// apiCheckHistoryHandler returns the recorded check results, with the same parameters as apiHistoryHandler
+func (d *DashboardController) apiCheckHistoryHandler(rw http.ResponseWriter, r *http.Request, envs environments, store history.Store) {
+	query, ok := historyQuery(rw, r, envs, store)
+	if !ok {
+		return
+	}
+
+	checks := store.QueryChecks(query)
+	if checks == nil {
+		checks = []history.CheckResult{}
+	}
+	writeJSON(rw, http.StatusOK, checks)
+}
+
+// historyQuery parses the query parameters of the history apis, errors are written to rw
+func historyQuery(rw http.ResponseWriter, r *http.Request, envs environments, store history.Store) (history.Query, bool) {
+	if store == nil {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "history is not enabled, see -history-file"})
+		return history.Query{}, false
+	}
+
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "environment " + r.URL.Query().Get("environment") + " not found"})
+		return history.Query{}, false
+	}
+
+	now := time.Now()
+	query := history.Query{
+		Environment: statusFetcher.Environment(),
+		Application: r.URL.Query().Get("application"),
+		From:        now.Add(-defaultHistoryPeriod),
+	}
+
+	var err error
+	if since := r.URL.Query().Get("since"); since != "" {
+		if query.From, err = parseHistoryTime(since, now); err != nil {
+			writeJSON(rw, http.StatusBadRequest, apiError{Error: err.Error()})
+			return history.Query{}, false
+		}
+	}
+	if until := r.URL.Query().Get("until"); until != "" {
+		if query.To, err = parseHistoryTime(until, now); err != nil {
+			writeJSON(rw, http.StatusBadRequest, apiError{Error: err.Error()})
+			return history.Query{}, false
+		}
+	}
+
+	return query, true
+}
+
+// parseHistoryTime parses a RFC3339 time or a duration before now
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected RFC3339 or a duration like 12h", value)
+	}
+	return t, nil
+}
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,7 +18,9 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
 	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/notify"
 	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/vistecture"
 )
 
@@ -28,14 +31,62 @@ type (
 		Listen          string
 		IgnoredServices []string
 		DemoMode        bool
+		// Namespaces to check, the first one is the default for apps without k8sNamespace property
+		Namespaces    []string
+		AllNamespaces bool
+		// Environments to check, the first one is shown by default
+		Environments []Environment
+		// CronJobMissedPeriods is the number of schedule periods without successful run until a CronJob is degraded
+		CronJobMissedPeriods int
+		// CheckConcurrency apps are checked at the same time, each after a random wait up to CheckJitter and within CheckTimeout
+		CheckConcurrency int
+		CheckJitter      time.Duration
+		CheckTimeout     time.Duration
+		// HistoryFile is the database persisting the state changes and check results if set,
+		// state changes older than HistoryRetention and check results older than HistoryCheckRetention are removed
+		HistoryFile           string
+		HistoryRetention      time.Duration
+		HistoryCheckRetention time.Duration
+		// Webhooks receive the state transitions of the apps as JSON, once the state is stable for NotifyDebounce
+		Webhooks       []string
+		NotifyDebounce time.Duration
+		// ChatWebhooks receive chat messages of the transitions of the apps of a team, given as "team=url" or "url" for all other teams
+		ChatWebhooks []string
+		// PublicURL is the URL the dashboard is reachable at, for the links in notifications
+		PublicURL string
+		// AlertmanagerURL receives alerts for failed and unhealthy apps if set
+		AlertmanagerURL string
 	}
 
 	ByName []kube.AppDeploymentInfo
 
+	// layoutData holds the info shared by all pages
+	layoutData struct {
+		Title string
+		// Root is the relative path to the dashboard root, to keep working behind a path prefix
+		Root         string
+		Now          time.Time
+		FetchStatus  kube.FetchStatus
+		Environment  string
+		Environments []string
+		// LiveUpdates is set for pages updating themselves, all other pages are reloaded regularly
+		LiveUpdates bool
+		// ViewRoot is the relative path to the dashboard of the selected sub view, team or group, the same as Root without
+		ViewRoot string
+		View     string
+		Views    []viewLink
+	}
+
 	// templateData holds info for Dashboard Rendering
 	templateData struct {
-		Failed, Unhealthy, Healthy, Unknown, Unstable, Ignored []kube.AppDeploymentInfo
-		Now                                                    time.Time
+		layoutData
+		Failed, Unhealthy, Impacted, Degraded, Healthy, Unknown, Unstable, Ignored []kube.AppDeploymentInfo
+	}
+
+	// templateSection is a table section for all apps in a state
+	templateSection struct {
+		State, Title string
+		Apps         []kube.AppDeploymentInfo
 	}
 )
 
@@ -43,6 +94,7 @@ type (
 func (d *DashboardController) Server() error {
 	// load once (will panic before we start listen)
 	project := vistecture.LoadProject(d.ProjectPath)
+	scopes := newPageScopes(vistecture.LoadSubViews(d.ProjectPath), project.Applications)
 
 	var fakeHealthcheckPort int32
 	if d.DemoMode {
@@ -51,26 +103,161 @@ func (d *DashboardController) Server() error {
 		fakeHealthcheckPort = <-portReceive
 	}
 
-	// Prepare the status fetcher (will run in background and starts regual checks)
-	statusFetcher := kube.NewStatusFetcher(project.Applications, d.DemoMode, fakeHealthcheckPort)
-	go statusFetcher.FetchStatusInRegularInterval(d.IgnoredServices)
+	var store history.Store
+	if d.HistoryFile != "" {
+		boltStore, err := history.OpenBoltStore(d.HistoryFile, d.HistoryRetention, d.HistoryCheckRetention)
+		if err != nil {
+			return fmt.Errorf("opening the history failed: %w", err)
+		}
+		defer boltStore.Close()
+		go boltStore.PruneInRegularInterval()
+		store = boltStore
+	}
+
+	configuredEnvironments := d.Environments
+	if len(configuredEnvironments) == 0 {
+		configuredEnvironments = []Environment{{Name: defaultEnvironment}}
+	}
+
+	notifiers := d.notifiers()
+	if d.AlertmanagerURL != "" {
+		alertmanager := notify.NewAlertmanagerNotifier(d.AlertmanagerURL, d.PublicURL)
+		go alertmanager.ResendInRegularInterval()
+		notifiers = append(notifiers, alertmanager)
+	}
+	var dispatcher *notify.Dispatcher
+	if len(notifiers) > 0 {
+		dispatcher = notify.NewDispatcher(notifiers, d.NotifyDebounce)
+		go dispatcher.FlushInRegularInterval()
+	}
+
+	// Prepare a status fetcher per environment (will run in background and starts regual checks)
+	var envs environments
+	for _, environment := range configuredEnvironments {
+		var kubeInfoService kube.KubeInfoServiceInterface = kube.NewKubeInfoService(environment.KubeContext, d.Namespaces, d.AllNamespaces)
+		if d.DemoMode {
+			kubeInfoService = kube.NewDemoService(fakeHealthcheckPort)
+		}
+
+		statusFetcher := kube.NewStatusFetcher(environment.Name, project.Applications, kubeInfoService)
+		statusFetcher.CronJobMissedPeriods = d.CronJobMissedPeriods
+		statusFetcher.CheckConcurrency = d.CheckConcurrency
+		statusFetcher.CheckJitter = d.CheckJitter
+		statusFetcher.CheckTimeout = d.CheckTimeout
+		statusFetcher.History = store
+		if dispatcher != nil {
+			statusFetcher.OnUpdate(dispatcher.Update)
+		}
+		go statusFetcher.FetchStatusInRegularInterval(d.IgnoredServices)
+		envs = append(envs, statusFetcher)
+	}
 
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(path.Join(d.Templates, "static")))))
 	http.Handle("/metrics", promhttp.Handler())
+	// all pages and the api are available for the whole project and restricted to a sub view, team or group
+	for _, prefix := range append([]string{""}, scopePrefixes...) {
+		http.HandleFunc("GET "+prefix+"/api/v1/apps", func(w http.ResponseWriter, r *http.Request) {
+			d.apiAppsHandler(w, r, envs, scopes)
+		})
+		http.HandleFunc("GET "+prefix+"/api/v1/apps/{name}", func(w http.ResponseWriter, r *http.Request) {
+			d.apiAppHandler(w, r, envs, scopes)
+		})
+		http.HandleFunc("GET "+prefix+"/api/v1/summary", func(w http.ResponseWriter, r *http.Request) {
+			d.apiSummaryHandler(w, r, envs, scopes)
+		})
+		http.HandleFunc("GET "+prefix+"/app/{name}", func(w http.ResponseWriter, r *http.Request) {
+			d.appHandler(w, r, envs, scopes, store)
+		})
+		http.HandleFunc("GET "+prefix+"/events", func(w http.ResponseWriter, r *http.Request) {
+			d.eventsHandler(w, r, envs, scopes)
+		})
+		http.HandleFunc("GET "+prefix+"/matrix", func(w http.ResponseWriter, r *http.Request) {
+			d.matrixHandler(w, r, envs, scopes, project.Applications)
+		})
+		http.HandleFunc("GET "+prefix+"/graph", func(w http.ResponseWriter, r *http.Request) {
+			d.graphHandler(w, r, envs, scopes, project, "")
+		})
+		http.HandleFunc("GET "+prefix+"/graph.svg", func(w http.ResponseWriter, r *http.Request) {
+			d.graphHandler(w, r, envs, scopes, project, "svg")
+		})
+		http.HandleFunc("GET "+prefix+"/graph.dot", func(w http.ResponseWriter, r *http.Request) {
+			d.graphHandler(w, r, envs, scopes, project, "dot")
+		})
+	}
+	http.HandleFunc("GET /api/v1/history", func(w http.ResponseWriter, r *http.Request) {
+		d.apiHistoryHandler(w, r, envs, store)
+	})
+	http.HandleFunc("GET /api/v1/history/checks", func(w http.ResponseWriter, r *http.Request) {
+		d.apiCheckHistoryHandler(w, r, envs, store)
+	})
+	http.HandleFunc("GET /availability", func(w http.ResponseWriter, r *http.Request) {
+		d.availabilityHandler(w, r, envs, scopes)
+	})
+	http.HandleFunc("GET /availability.csv", func(w http.ResponseWriter, r *http.Request) {
+		d.availabilityCSVHandler(w, r, envs)
+	})
+	http.HandleFunc("GET /api/v1/availability", func(w http.ResponseWriter, r *http.Request) {
+		d.apiAvailabilityHandler(w, r, envs)
+	})
+	http.HandleFunc("GET /teams", func(w http.ResponseWriter, r *http.Request) {
+		d.rollUpHandler(w, r, envs, scopes)
+	})
+	http.HandleFunc("GET /api/v1/teams", func(w http.ResponseWriter, r *http.Request) {
+		d.apiRollUpHandler(w, r, envs, scopes, scopeTeam)
+	})
+	http.HandleFunc("GET /api/v1/groups", func(w http.ResponseWriter, r *http.Request) {
+		d.apiRollUpHandler(w, r, envs, scopes, scopeGroup)
+	})
+	for _, prefix := range scopePrefixes {
+		http.HandleFunc("GET "+prefix, func(w http.ResponseWriter, r *http.Request) {
+			target := *r.URL
+			target.Path += "/"
+			http.Redirect(w, r, target.String(), http.StatusMovedPermanently)
+		})
+		http.HandleFunc("GET "+prefix+"/{$}", func(w http.ResponseWriter, r *http.Request) {
+			d.dashBoardHandler(w, r, envs, scopes)
+		})
+	}
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		d.dashBoardHandler(w, r, statusFetcher)
+		d.dashBoardHandler(w, r, envs, scopes)
 	})
 
 	log.Println("Listening on http://" + d.Listen + "/")
 	return http.ListenAndServe(d.Listen, nil)
 }
 
+// notifiers returns the configured webhook and chat notifiers
+func (d *DashboardController) notifiers() []notify.Notifier {
+	var notifiers []notify.Notifier
+	for _, url := range d.Webhooks {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(url))
+	}
+	if len(d.ChatWebhooks) > 0 {
+		notifiers = append(notifiers, notify.NewChatNotifier(d.ChatWebhooks, d.PublicURL))
+	}
+
+	return notifiers
+}
+
 // dashBoardHandler handles the view Request
-func (d *DashboardController) dashBoardHandler(rw http.ResponseWriter, _ *http.Request, statusFetcher *kube.StatusFetcher) {
+func (d *DashboardController) dashBoardHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
 	viewdata := templateData{
-		Now: time.Now(),
+		layoutData: newLayoutData(r, "Status", envs, statusFetcher, scopes, current),
 	}
-	result := statusFetcher.GetCurrentResult()
+	viewdata.LiveUpdates = true
+
+	result := appFilter{Applications: current.applications()}.apply(statusFetcher.GetCurrentResult())
 	for _, deployment := range result {
 		switch deployment.AppStateInfo.State {
 		case kube.State_ignored:
@@ -85,6 +272,10 @@ func (d *DashboardController) dashBoardHandler(rw http.ResponseWriter, _ *http.R
 			viewdata.Healthy = append(viewdata.Healthy, deployment)
 		case kube.State_unstable:
 			viewdata.Unstable = append(viewdata.Unstable, deployment)
+		case kube.State_degraded:
+			viewdata.Degraded = append(viewdata.Degraded, deployment)
+		case kube.State_impacted:
+			viewdata.Impacted = append(viewdata.Impacted, deployment)
 		}
 	}
 
@@ -92,15 +283,73 @@ func (d *DashboardController) dashBoardHandler(rw http.ResponseWriter, _ *http.R
 	sort.Sort(ByName(viewdata.Unknown))
 	sort.Sort(ByName(viewdata.Unhealthy))
 	sort.Sort(ByName(viewdata.Unstable))
+	sort.Sort(ByName(viewdata.Degraded))
+	sort.Sort(ByName(viewdata.Impacted))
 	sort.Sort(ByName(viewdata.Failed))
 	sort.Sort(ByName(viewdata.Healthy))
 
-	d.renderDashboardStatus(rw, viewdata)
+	d.render(rw, "dashboard", viewdata)
+}
+
+// newLayoutData prepares the info shared by all pages for the environment of the statusFetcher and the scope (nil for the whole project)
+func newLayoutData(r *http.Request, title string, envs environments, statusFetcher *kube.StatusFetcher, scopes pageScopes, current *scope) layoutData {
+	data := layoutData{
+		Title:        title,
+		Root:         relativeRoot(r),
+		Now:          time.Now(),
+		FetchStatus:  statusFetcher.GetFetchStatus(),
+		Environment:  statusFetcher.Environment(),
+		Environments: envs.names(),
+		ViewRoot:     viewRoot(r, current),
+		Views:        scopes.links(r, current),
+	}
+	if current != nil {
+		data.View = current.Name
+		data.Title += " - " + current.Name
+	}
+
+	return data
+}
+
+// relativeRoot returns the relative path from the requested page to the dashboard root, e.g. "../" for /team/name
+func relativeRoot(r *http.Request) string {
+	depth := strings.Count(r.URL.Path, "/") - 1
+	if depth < 1 {
+		return "./"
+	}
+
+	return strings.Repeat("../", depth)
+}
+
+// render passes Viewdata to the Template of the page
+func (d *DashboardController) render(rw http.ResponseWriter, page string, viewdata interface{}) {
+	d.renderTemplate(rw, page, page, "text/html", viewdata)
 }
 
-// renderDashboardStatus passes Viewdata to Template
-func (d *DashboardController) renderDashboardStatus(rw http.ResponseWriter, viewdata templateData) {
-	tpl := template.New("dashboard")
+// renderTemplate executes a template defined in the file of the page, e.g. the svg of the graph page
+func (d *DashboardController) renderTemplate(rw http.ResponseWriter, page string, name string, contentType string, viewdata interface{}) {
+	tpl, err := d.loadTemplate(page)
+	if err != nil {
+		e(rw, err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	err = tpl.ExecuteTemplate(buf, name, viewdata)
+
+	if err != nil {
+		e(rw, err)
+		return
+	}
+
+	rw.Header().Set("content-type", contentType)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(rw, buf)
+}
+
+// loadTemplate parses the page (e.g. dashboard.html) together with the shared layout.html
+func (d *DashboardController) loadTemplate(page string) (*template.Template, error) {
+	tpl := template.New(page)
 
 	tpl.Funcs(template.FuncMap{
 		"ignored":   func() uint { return kube.State_ignored },
@@ -109,36 +358,84 @@ func (d *DashboardController) renderDashboardStatus(rw http.ResponseWriter, view
 		"failed":    func() uint { return kube.State_failed },
 		"healthy":   func() uint { return kube.State_healthy },
 		"unstable":  func() uint { return kube.State_unstable },
+		"degraded":  func() uint { return kube.State_degraded },
+		"impacted":  func() uint { return kube.State_impacted },
+		"stateName": kube.StateName,
+		"section": func(state, title string, apps []kube.AppDeploymentInfo) templateSection {
+			return templateSection{State: state, Title: title, Apps: apps}
+		},
+		"availabilityTable": func(page availabilityData, reports []kube.AvailabilityReport, apps bool) availabilityTable {
+			return availabilityTable{Page: page, Reports: reports, Apps: apps}
+		},
 		"splitLines": func(s string) []string {
 			return strings.Split(s, "\n")
 		},
+		"formatDuration": formatDuration,
+		"formatLatency":  formatLatency,
+		"sparkline":      sparkline,
 	})
 
-	b, err := os.ReadFile(path.Join(d.Templates, "dashboard.html"))
+	for _, file := range []string{"layout.html", page + ".html"} {
+		b, err := os.ReadFile(path.Join(d.Templates, file))
+		if err != nil {
+			return nil, err
+		}
 
-	if err != nil {
-		e(rw, err)
-		return
+		if _, err = tpl.Parse(string(b)); err != nil {
+			return nil, err
+		}
 	}
 
-	tpl, err = tpl.Parse(string(b))
+	return tpl, nil
+}
 
-	if err != nil {
-		e(rw, err)
-		return
+// formatDuration rounds the duration for display, e.g. 3d4h, 2h15m or 42s
+func formatDuration(duration time.Duration) string {
+	switch {
+	case duration >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", duration/(24*time.Hour), duration%(24*time.Hour)/time.Hour)
+	case duration >= time.Hour:
+		duration = duration.Round(time.Minute)
+		return fmt.Sprintf("%dh%dm", duration/time.Hour, duration%time.Hour/time.Minute)
 	}
 
-	buf := new(bytes.Buffer)
-	err = tpl.ExecuteTemplate(buf, "dashboard", viewdata)
+	return duration.Round(time.Second).String()
+}
 
-	if err != nil {
-		e(rw, err)
-		return
+// formatLatency rounds the duration of a check to milliseconds, e.g. 123ms or 1.234s
+func formatLatency(duration time.Duration) string {
+	return duration.Round(time.Millisecond).String()
+}
+
+// sparklineWidth and sparklineHeight are the size of the sparkline in pixels
+const (
+	sparklineWidth  = 120
+	sparklineHeight = 24
+)
+
+// sparkline draws the durations as SVG line, scaled to the longest one
+func sparkline(durations []time.Duration) template.HTML {
+	if len(durations) < 2 {
+		return ""
 	}
 
-	rw.Header().Set("content-type", "text/html")
-	rw.WriteHeader(http.StatusOK)
-	_, _ = io.Copy(rw, buf)
+	longest := slices.Max(durations)
+	if longest <= 0 {
+		return ""
+	}
+
+	points := make([]string, len(durations))
+	for i, d := range durations {
+		x := float64(i) * sparklineWidth / float64(len(durations)-1)
+		y := sparklineHeight - 1 - float64(d)*(sparklineHeight-2)/float64(longest)
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<svg class="sparkline" width="%d" height="%d" viewBox="0 0 %d %d"><title>last %d checks, %s to %s</title><polyline points="%s"/></svg>`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight,
+		len(durations), formatLatency(slices.Min(durations)), formatLatency(longest), strings.Join(points, " "),
+	))
 }
 
 func (a ByName) Len() int           { return len(a) }
@@ -0,0 +1,97 @@
+package interfaces
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// matrixData holds the state of every app in every environment
+	matrixData struct {
+		layoutData
+		Rows []matrixRow
+		// Stale lists the environments currently showing outdated results
+		Stale []string
+	}
+
+	// matrixRow is an app with one cell per environment, in the order of layoutData.Environments
+	matrixRow struct {
+		Name  string
+		Title string
+		Team  string
+		Cells []matrixCell
+	}
+
+	matrixCell struct {
+		Environment string
+		// Checked is false if the environment has no result for the app (yet)
+		Checked    bool
+		Deployment kube.AppDeploymentInfo
+	}
+)
+
+// matrixHandler shows the state of all apps side by side for all environments
+func (d *DashboardController) matrixHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes, apps []*vistectureCore.Application) {
+	current, ok := scopes.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	if current != nil {
+		var included []*vistectureCore.Application
+		for _, app := range apps {
+			if slices.Contains(current.Applications, app.Name) {
+				included = append(included, app)
+			}
+		}
+		apps = included
+	}
+
+	viewdata := matrixData{
+		layoutData: newLayoutData(r, "Matrix", envs, envs[0], scopes, current),
+		Rows:       buildMatrix(envs, apps),
+	}
+
+	for _, statusFetcher := range envs {
+		if statusFetcher.GetFetchStatus().Stale() {
+			viewdata.Stale = append(viewdata.Stale, statusFetcher.Environment())
+		}
+	}
+	// the matrix is not bound to a single environment, the banner of the layout is replaced by the list of stale ones
+	viewdata.Environment = ""
+	viewdata.FetchStatus = kube.FetchStatus{}
+
+	d.render(rw, "matrix", viewdata)
+}
+
+// buildMatrix returns a row per vistecture app, ordered by name
+func buildMatrix(envs environments, apps []*vistectureCore.Application) []matrixRow {
+	results := make([]map[string]kube.AppDeploymentInfo, len(envs))
+	for i, statusFetcher := range envs {
+		results[i] = statusFetcher.GetCurrentResult()
+	}
+
+	rows := make([]matrixRow, 0, len(apps))
+	for _, app := range apps {
+		row := matrixRow{Name: app.Name, Title: app.Title, Team: app.Team}
+		for i, statusFetcher := range envs {
+			deployment, checked := results[i][app.Name]
+			row.Cells = append(row.Cells, matrixCell{
+				Environment: statusFetcher.Environment(),
+				Checked:     checked,
+				Deployment:  deployment,
+			})
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Name < rows[j].Name })
+
+	return rows
+}
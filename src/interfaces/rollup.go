@@ -0,0 +1,143 @@
+package interfaces
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// rollUpData is the overview of all teams and groups of an environment
+	rollUpData struct {
+		layoutData
+		Teams, Groups []rollUp
+	}
+
+	// rollUp aggregates the states of the apps of a team or group
+	rollUp struct {
+		Name string
+		// URL is the relative link to the dashboard of the team or group
+		URL        string
+		Total      int
+		WorstState uint
+		// Counts are the number of apps per state, the worst state first
+		Counts []stateCount
+		// Failing are the apps whose own check failed or is unhealthy
+		Failing []kube.AppDeploymentInfo
+	}
+
+	stateCount struct {
+		State uint
+		Count int
+	}
+
+	// apiRollUp is the JSON representation of a rollUp
+	apiRollUp struct {
+		Name       string         `json:"name"`
+		Total      int            `json:"total"`
+		WorstState string         `json:"worstState"`
+		States     map[string]int `json:"states"`
+		Failing    []string       `json:"failing"`
+	}
+)
+
+// rollUpHandler shows the aggregated states of all teams and groups
+func (d *DashboardController) rollUpHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		http.NotFound(rw, r)
+		return
+	}
+
+	result := statusFetcher.GetCurrentResult()
+	viewdata := rollUpData{
+		layoutData: newLayoutData(r, "Teams", envs, statusFetcher, scopes, nil),
+		Teams:      buildRollUps(r, scopes.teams, result),
+		Groups:     buildRollUps(r, scopes.groups, result),
+	}
+
+	d.render(rw, "teams", viewdata)
+}
+
+// apiRollUpHandler returns the aggregated states of all teams or groups (kind) of the environment given in the query (or the default)
+func (d *DashboardController) apiRollUpHandler(rw http.ResponseWriter, r *http.Request, envs environments, scopes pageScopes, kind string) {
+	statusFetcher, ok := envs.fromRequest(r)
+	if !ok {
+		writeJSON(rw, http.StatusNotFound, apiError{Error: "environment " + r.URL.Query().Get("environment") + " not found"})
+		return
+	}
+
+	list := scopes.teams
+	if kind == scopeGroup {
+		list = scopes.groups
+	}
+
+	result := []apiRollUp{}
+	for _, rollUp := range buildRollUps(r, list, statusFetcher.GetCurrentResult()) {
+		result = append(result, toApiRollUp(rollUp))
+	}
+
+	writeJSON(rw, http.StatusOK, result)
+}
+
+// buildRollUps aggregates the results of the apps of each scope, apps without result (yet) are left out
+func buildRollUps(r *http.Request, list []scope, result map[string]kube.AppDeploymentInfo) []rollUp {
+	query := ""
+	if environment := r.URL.Query().Get("environment"); environment != "" {
+		query = "?environment=" + url.QueryEscape(environment)
+	}
+
+	rollUps := make([]rollUp, 0, len(list))
+	for i := range list {
+		rollUp := rollUp{Name: list[i].Name, URL: viewRoot(r, &list[i]) + query, WorstState: kube.State_unknown}
+		counts := make(map[uint]int)
+		for _, deployment := range (appFilter{Applications: list[i].Applications}).apply(result) {
+			state := deployment.AppStateInfo.State
+			if rollUp.Total == 0 || kube.StateSeverity(state) > kube.StateSeverity(rollUp.WorstState) {
+				rollUp.WorstState = state
+			}
+			rollUp.Total++
+			counts[state]++
+
+			ownState := state
+			if state == kube.State_impacted {
+				ownState = deployment.AppStateInfo.OwnState
+			}
+			if kube.IsBroken(ownState) {
+				rollUp.Failing = append(rollUp.Failing, deployment)
+			}
+		}
+
+		for state, count := range counts {
+			rollUp.Counts = append(rollUp.Counts, stateCount{State: state, Count: count})
+		}
+		sort.Slice(rollUp.Counts, func(a, b int) bool {
+			return kube.StateSeverity(rollUp.Counts[a].State) > kube.StateSeverity(rollUp.Counts[b].State)
+		})
+
+		rollUps = append(rollUps, rollUp)
+	}
+
+	return rollUps
+}
+
+func toApiRollUp(rollUp rollUp) apiRollUp {
+	api := apiRollUp{
+		Name:       rollUp.Name,
+		Total:      rollUp.Total,
+		WorstState: kube.StateName(rollUp.WorstState),
+		States:     make(map[string]int, len(rollUp.Counts)),
+		Failing:    make([]string, 0, len(rollUp.Failing)),
+	}
+
+	for _, count := range rollUp.Counts {
+		api.States[kube.StateName(count.State)] = count.Count
+	}
+	for _, deployment := range rollUp.Failing {
+		api.Failing = append(api.Failing, deployment.VistectureApp.Name)
+	}
+
+	return api
+}
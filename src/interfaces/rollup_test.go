@@ -0,0 +1,59 @@
+package interfaces
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestBuildRollUps(t *testing.T) {
+	result := map[string]kube.AppDeploymentInfo{
+		"flamingo": {
+			VistectureApp: vistectureCore.Application{Name: "flamingo"},
+			AppStateInfo:  kube.AppStateInfo{State: kube.State_impacted, OwnState: kube.State_unhealthy},
+		},
+		"shop": {
+			VistectureApp: vistectureCore.Application{Name: "shop"},
+			AppStateInfo:  kube.AppStateInfo{State: kube.State_impacted, OwnState: kube.State_healthy},
+		},
+		"akeneo": {
+			VistectureApp: vistectureCore.Application{Name: "akeneo"},
+			AppStateInfo:  kube.AppStateInfo{State: kube.State_failed},
+		},
+		"keycloak": {
+			VistectureApp: vistectureCore.Application{Name: "keycloak"},
+			AppStateInfo:  kube.AppStateInfo{State: kube.State_healthy},
+		},
+	}
+	teams := []scope{
+		{Kind: scopeTeam, Name: "Team 1", Applications: []string{"flamingo", "shop", "keycloak"}},
+		{Kind: scopeTeam, Name: "Team 2", Applications: []string{"akeneo", "keycloak"}},
+		{Kind: scopeTeam, Name: "Team 3", Applications: []string{"unchecked"}},
+	}
+
+	rollUps := buildRollUps(httptest.NewRequest("GET", "/teams?environment=prod", nil), teams, result)
+
+	api := make([]apiRollUp, 0, len(rollUps))
+	for _, rollUp := range rollUps {
+		api = append(api, toApiRollUp(rollUp))
+	}
+	expected := []apiRollUp{
+		{Name: "Team 1", Total: 3, WorstState: "impacted", States: map[string]int{"impacted": 2, "healthy": 1}, Failing: []string{"flamingo"}},
+		{Name: "Team 2", Total: 2, WorstState: "failed", States: map[string]int{"failed": 1, "healthy": 1}, Failing: []string{"akeneo"}},
+		{Name: "Team 3", Total: 0, WorstState: "unknown", States: map[string]int{}, Failing: []string{}},
+	}
+	if !reflect.DeepEqual(api, expected) {
+		t.Errorf("expected %+v, got %+v", expected, api)
+	}
+
+	if rollUps[1].Counts[0].State != kube.State_failed {
+		t.Errorf("expected the worst state to be counted first, got %v", rollUps[1].Counts)
+	}
+	if rollUps[0].URL != "./team/Team%201/?environment=prod" {
+		t.Errorf("expected the link to the team dashboard, got %q", rollUps[0].URL)
+	}
+}
@@ -0,0 +1,136 @@
+package interfaces
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/vistecture"
+)
+
+type (
+	// scope restricts the pages and the api to the apps of a sub view, a team or a group, it is served below /{kind}/{name}/
+	scope struct {
+		Kind, Name   string
+		Applications []string
+	}
+
+	// pageScopes are the sub views of the project and the teams and groups of its apps
+	pageScopes struct {
+		views, teams, groups []scope
+	}
+
+	// viewLink is an entry of the sub view selector
+	viewLink struct {
+		Name, URL string
+		Active    bool
+	}
+)
+
+// kinds of scopes, used as first path segment and as name of the path value
+const (
+	scopeView  = "view"
+	scopeTeam  = "team"
+	scopeGroup = "group"
+)
+
+// scopePrefixes are the route prefixes of the pages and api restricted to a scope
+var scopePrefixes = []string{"/" + scopeView + "/{" + scopeView + "}", "/" + scopeTeam + "/{" + scopeTeam + "}", "/" + scopeGroup + "/{" + scopeGroup + "}"}
+
+// newPageScopes creates the scopes of the sub views and of all teams and groups of the apps, ordered by name
+func newPageScopes(subViews []vistecture.SubView, apps []*vistectureCore.Application) pageScopes {
+	var scopes pageScopes
+	for _, subView := range subViews {
+		applications := subView.Applications
+		if applications == nil {
+			applications = []string{}
+		}
+		scopes.views = append(scopes.views, scope{Kind: scopeView, Name: subView.Name, Applications: applications})
+	}
+
+	scopes.teams = groupScopes(scopeTeam, apps, func(app *vistectureCore.Application) string { return app.Team })
+	scopes.groups = groupScopes(scopeGroup, apps, func(app *vistectureCore.Application) string { return app.Group })
+
+	return scopes
+}
+
+// groupScopes creates a scope for every value of key, apps with an empty value are left out
+func groupScopes(kind string, apps []*vistectureCore.Application, key func(app *vistectureCore.Application) string) []scope {
+	index := make(map[string]int)
+	var scopes []scope
+	for _, app := range apps {
+		name := key(app)
+		if name == "" {
+			continue
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(scopes)
+			index[name] = i
+			scopes = append(scopes, scope{Kind: kind, Name: name})
+		}
+		scopes[i].Applications = append(scopes[i].Applications, app.Name)
+	}
+
+	sort.Slice(scopes, func(i, j int) bool { return scopes[i].Name < scopes[j].Name })
+	return scopes
+}
+
+// fromRequest returns the scope given by the path, nil for requests that are not restricted to a scope
+func (scopes pageScopes) fromRequest(r *http.Request) (*scope, bool) {
+	for kind, list := range map[string][]scope{scopeView: scopes.views, scopeTeam: scopes.teams, scopeGroup: scopes.groups} {
+		name := r.PathValue(kind)
+		if name == "" {
+			continue
+		}
+
+		for i := range list {
+			if list[i].Name == name {
+				return &list[i], true
+			}
+		}
+		return nil, false
+	}
+
+	return nil, true
+}
+
+// viewRoot returns the relative path from the requested page to the root of the scope, or the dashboard root without scope
+func viewRoot(r *http.Request, current *scope) string {
+	if current == nil {
+		return relativeRoot(r)
+	}
+
+	return relativeRoot(r) + current.Kind + "/" + url.PathEscape(current.Name) + "/"
+}
+
+// links returns the entries of the selector for the whole project and all sub views, keeping the environment
+func (scopes pageScopes) links(r *http.Request, current *scope) []viewLink {
+	query := ""
+	if environment := r.URL.Query().Get("environment"); environment != "" {
+		query = "?environment=" + url.QueryEscape(environment)
+	}
+
+	links := []viewLink{{Name: "All applications", URL: relativeRoot(r) + query, Active: current == nil}}
+	for i := range scopes.views {
+		links = append(links, viewLink{
+			Name:   scopes.views[i].Name,
+			URL:    viewRoot(r, &scopes.views[i]) + query,
+			Active: current != nil && current.Kind == scopeView && current.Name == scopes.views[i].Name,
+		})
+	}
+
+	return links
+}
+
+// applications returns the apps of the scope, nil (all apps) without scope
+func (s *scope) applications() []string {
+	if s == nil {
+		return nil
+	}
+
+	return s.Applications
+}
@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/vistecture"
+)
+
+func TestPageScopes_FromRequest(t *testing.T) {
+	scopes := newPageScopes(
+		[]vistecture.SubView{
+			{Name: "Frontend", Applications: []string{"flamingo", "akeneo"}},
+			{Name: "empty"},
+		},
+		[]*vistectureCore.Application{
+			{Name: "flamingo", Team: "Team 1", Group: "Frontend"},
+			{Name: "keycloak", Team: "Team 2"},
+			{Name: "akeneo", Team: "Team 1"},
+		},
+	)
+
+	if !reflect.DeepEqual(scopes.teams, []scope{
+		{Kind: scopeTeam, Name: "Team 1", Applications: []string{"flamingo", "akeneo"}},
+		{Kind: scopeTeam, Name: "Team 2", Applications: []string{"keycloak"}},
+	}) {
+		t.Errorf("expected the apps grouped by team, got %v", scopes.teams)
+	}
+	if len(scopes.groups) != 1 || scopes.groups[0].Name != "Frontend" {
+		t.Errorf("expected a single group, got %v", scopes.groups)
+	}
+
+	r := httptest.NewRequest("GET", "/view/Frontend/graph?environment=prod", nil)
+	r.SetPathValue(scopeView, "Frontend")
+	current, ok := scopes.fromRequest(r)
+	if !ok || current == nil || current.Kind != scopeView || current.Name != "Frontend" {
+		t.Fatalf("expected the sub view Frontend, got %v %v", current, ok)
+	}
+	if root := viewRoot(r, current); root != "../../view/Frontend/" {
+		t.Errorf("expected the relative view root ../../view/Frontend/, got %q", root)
+	}
+
+	links := scopes.links(r, current)
+	expected := []viewLink{
+		{Name: "All applications", URL: "../../?environment=prod"},
+		{Name: "Frontend", URL: "../../view/Frontend/?environment=prod", Active: true},
+		{Name: "empty", URL: "../../view/empty/?environment=prod"},
+	}
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("expected %v, got %v", expected, links)
+	}
+
+	r = httptest.NewRequest("GET", "/team/Team%201/", nil)
+	r.SetPathValue(scopeTeam, "Team 1")
+	current, ok = scopes.fromRequest(r)
+	if !ok || current == nil || current.Kind != scopeTeam {
+		t.Fatalf("expected the team Team 1, got %v %v", current, ok)
+	}
+	if root := viewRoot(r, current); root != "../../team/Team%201/" {
+		t.Errorf("expected the relative team root ../../team/Team%%201/, got %q", root)
+	}
+
+	r = httptest.NewRequest("GET", "/view/unknown/", nil)
+	r.SetPathValue(scopeView, "unknown")
+	if _, ok := scopes.fromRequest(r); ok {
+		t.Error("expected an unknown sub view not to be found")
+	}
+
+	current, ok = scopes.fromRequest(httptest.NewRequest("GET", "/graph", nil))
+	if !ok || current != nil || current.applications() != nil {
+		t.Errorf("expected no restriction without path value, got %v", current)
+	}
+
+	if apps := scopes.views[1].applications(); apps == nil || len(apps) != 0 {
+		t.Errorf("expected an empty sub view to match no apps, got %v", apps)
+	}
+}
@@ -0,0 +1,319 @@
+package history
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type (
+	// Record is a state of an application, it lasts until the next record of the application
+	Record struct {
+		Time        time.Time `json:"time"`
+		Environment string    `json:"environment"`
+		Application string    `json:"application"`
+		Team        string    `json:"team,omitempty"`
+		State       string    `json:"state"`
+		// OwnState is the state of the check of the application itself if it is impacted by a dependency
+		OwnState string `json:"ownState,omitempty"`
+		Reason   string `json:"reason,omitempty"`
+	}
+
+	// CheckResult is the result of a single check of an application, independent of its dependencies
+	CheckResult struct {
+		Time        time.Time `json:"time"`
+		Environment string    `json:"environment"`
+		Application string    `json:"application"`
+		State       string    `json:"state"`
+		Reason      string    `json:"reason,omitempty"`
+		DurationMs  int64     `json:"durationMs,omitempty"`
+	}
+
+	// Query selects records, empty fields match everything
+	Query struct {
+		Environment string
+		Application string
+		From, To    time.Time
+	}
+
+	// Store persists the records and check results
+	Store interface {
+		Append(records ...Record) error
+		AppendChecks(checks ...CheckResult) error
+		// Query returns the matching records ordered by time
+		Query(query Query) []Record
+		// QueryChecks returns the matching check results ordered by time
+		QueryChecks(query Query) []CheckResult
+		// Latest returns the most recent record of each application of the environment
+		Latest(environment string) map[string]Record
+	}
+
+	// BoltStore keeps the records and check results in a bbolt database file.
+	// The keys are environment, application and time, so the entries of an application are stored in order.
+	// Records older than the retention and check results older than the check retention are removed by Prune.
+	BoltStore struct {
+		db             *bolt.DB
+		retention      time.Duration
+		checkRetention time.Duration
+	}
+)
+
+// pruneInterval is the interval in which entries older than the retention are removed
+const pruneInterval = time.Hour
+
+// keyTimeLength is the length of the time and the sequence number at the end of a key
+const keyTimeLength = 16
+
+var (
+	recordsBucket = []byte("records")
+	checksBucket  = []byte("checks")
+)
+
+var _ Store = new(BoltStore)
+
+// OpenBoltStore opens the database file, it is created if it does not exist.
+// A retention of 0 keeps the entries forever.
+func OpenBoltStore(path string, retention time.Duration, checkRetention time.Duration) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening %v failed: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{recordsBucket, checksBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("initializing %v failed: %w", path, err)
+	}
+
+	s := &BoltStore{db: db, retention: retention, checkRetention: checkRetention}
+	if err := s.Prune(time.Now()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Printf("History: opened %v", path)
+	return s, nil
+}
+
+// Append stores the records
+func (s *BoltStore) Append(records ...Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(recordsBucket)
+		for _, record := range records {
+			if err := put(bucket, record.Environment, record.Application, record.Time, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AppendChecks stores the check results
+func (s *BoltStore) AppendChecks(checks ...CheckResult) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(checksBucket)
+		for _, check := range checks {
+			if err := put(bucket, check.Environment, check.Application, check.Time, check); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Query returns the matching records ordered by time
+func (s *BoltStore) Query(query Query) []Record {
+	return find[Record](s.db, recordsBucket, query)
+}
+
+// QueryChecks returns the matching check results ordered by time
+func (s *BoltStore) QueryChecks(query Query) []CheckResult {
+	return find[CheckResult](s.db, checksBucket, query)
+}
+
+// Latest returns the most recent record of each application of the environment
+func (s *BoltStore) Latest(environment string) map[string]Record {
+	latest := make(map[string]Record)
+	for _, record := range s.Query(Query{Environment: environment}) {
+		latest[record.Application] = record
+	}
+
+	return latest
+}
+
+// PruneInRegularInterval removes the entries older than their retention once an hour
+func (s *BoltStore) PruneInRegularInterval() {
+	for now := range time.Tick(pruneInterval) {
+		if err := s.Prune(now); err != nil {
+			log.Printf("History: pruning failed: %v", err)
+		}
+	}
+}
+
+// Prune removes the records older than the retention and the check results older than the check retention.
+// The last record before the retention is kept per application, as it is the state at the beginning of the retention.
+func (s *BoltStore) Prune(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := prune(tx.Bucket(recordsBucket), s.retention, now, true); err != nil {
+			return err
+		}
+		return prune(tx.Bucket(checksBucket), s.checkRetention, now, false)
+	})
+}
+
+// Close closes the database
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// put stores the value as JSON, the sequence number keeps entries of the same time apart
+func put(bucket *bolt.Bucket, environment string, application string, t time.Time, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	sequence, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	key := binary.BigEndian.AppendUint64(keyPrefix(environment, application), uint64(t.UnixNano()))
+	return bucket.Put(binary.BigEndian.AppendUint64(key, sequence), b)
+}
+
+// find returns the matching entries of the bucket ordered by time, errors are logged
+func find[T any](db *bolt.DB, bucket []byte, query Query) []T {
+	type entry struct {
+		time  time.Time
+		value T
+	}
+
+	var entries []entry
+	err := db.View(func(tx *bolt.Tx) error {
+		prefix := keyPrefix(query.Environment, query.Application)
+		start := prefix
+		if query.Environment != "" && query.Application != "" && !query.From.IsZero() {
+			start = binary.BigEndian.AppendUint64(bytes.Clone(prefix), uint64(query.From.UnixNano()))
+		}
+
+		cursor := tx.Bucket(bucket).Cursor()
+		for k, v := cursor.Seek(start); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			environment, application, t := parseKey(k)
+			if !query.matches(environment, application, t) {
+				continue
+			}
+
+			var value T
+			if err := json.Unmarshal(v, &value); err != nil {
+				log.Printf("History: skipping invalid entry %q: %v", k, err)
+				continue
+			}
+			entries = append(entries, entry{time: t, value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("History: query failed: %v", err)
+		return nil
+	}
+
+	// the entries are ordered by application first
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].time.Before(entries[j].time) })
+	values := make([]T, 0, len(entries))
+	for _, e := range entries {
+		values = append(values, e.value)
+	}
+
+	return values
+}
+
+// prune removes the entries older than the retention, keepLast keeps the last one before per application
+func prune(bucket *bolt.Bucket, retention time.Duration, now time.Time, keepLast bool) error {
+	if retention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-retention)
+
+	var stale [][]byte
+	var lastBefore []byte
+	cursor := bucket.Cursor()
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		if _, _, t := parseKey(k); !t.Before(cutoff) {
+			continue
+		}
+		if !keepLast {
+			stale = append(stale, bytes.Clone(k))
+			continue
+		}
+		if lastBefore != nil && bytes.Equal(lastBefore[:len(lastBefore)-keyTimeLength], k[:len(k)-keyTimeLength]) {
+			stale = append(stale, lastBefore)
+		}
+		lastBefore = bytes.Clone(k)
+	}
+
+	for _, k := range stale {
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+	}
+	if len(stale) > 0 {
+		log.Printf("History: removed %d entries older than %v", len(stale), retention)
+	}
+	return nil
+}
+
+// keyPrefix is the prefix of the keys of the application, of the environment if application is empty, or of all keys
+func keyPrefix(environment string, application string) []byte {
+	if environment == "" {
+		return nil
+	}
+
+	prefix := append([]byte(environment), 0)
+	if application != "" {
+		prefix = append(append(prefix, application...), 0)
+	}
+	return prefix
+}
+
+// parseKey splits a key into "environment \0 application \0 time sequence"
+func parseKey(key []byte) (string, string, time.Time) {
+	if len(key) < keyTimeLength+2 {
+		return "", "", time.Time{}
+	}
+
+	names := key[:len(key)-keyTimeLength-1]
+	environment, application, _ := bytes.Cut(names, []byte{0})
+	t := time.Unix(0, int64(binary.BigEndian.Uint64(key[len(key)-keyTimeLength:])))
+
+	return string(environment), string(application), t
+}
+
+func (q Query) matches(environment string, application string, t time.Time) bool {
+	if q.Environment != "" && environment != q.Environment {
+		return false
+	}
+	if q.Application != "" && application != q.Application {
+		return false
+	}
+	if !q.From.IsZero() && t.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && t.After(q.To) {
+		return false
+	}
+
+	return true
+}
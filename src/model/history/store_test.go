@@ -0,0 +1,113 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBoltStore_AppendAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	now := time.Now().Truncate(time.Second)
+
+	store, err := OpenBoltStore(path, 24*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Append(
+		Record{Time: now.Add(-time.Hour), Environment: "prod", Application: "flamingo", State: "failed", Reason: "No pod available"},
+		Record{Time: now.Add(-2 * time.Hour), Environment: "prod", Application: "flamingo", State: "healthy"},
+		Record{Time: now.Add(-time.Hour), Environment: "stage", Application: "flamingo", State: "healthy"},
+		Record{Time: now.Add(-90 * time.Minute), Environment: "prod", Application: "akeneo", State: "healthy"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AppendChecks(
+		CheckResult{Time: now.Add(-time.Minute), Environment: "prod", Application: "flamingo", State: "unhealthy", Reason: "HTTP 500", DurationMs: 120},
+		CheckResult{Time: now, Environment: "prod", Application: "flamingo", State: "healthy", DurationMs: 80},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = OpenBoltStore(path, 24*time.Hour, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	records := store.Query(Query{Environment: "prod", Application: "flamingo", From: now.Add(-90 * time.Minute)})
+	if len(records) != 1 || records[0].State != "failed" || records[0].Reason != "No pod available" {
+		t.Errorf("expected the failed record, got %+v", records)
+	}
+
+	latest := store.Latest("prod")
+	if len(latest) != 2 || latest["flamingo"].State != "failed" || latest["akeneo"].State != "healthy" {
+		t.Errorf("expected the latest prod records, got %+v", latest)
+	}
+
+	records = store.Query(Query{Environment: "prod"})
+	if len(records) != 3 || records[0].State != "healthy" || records[1].Application != "akeneo" || records[2].State != "failed" {
+		t.Errorf("expected 3 prod records ordered by time, got %+v", records)
+	}
+
+	if records := store.Query(Query{Application: "flamingo", To: now.Add(-90 * time.Minute)}); len(records) != 1 {
+		t.Errorf("expected the first flamingo record, got %+v", records)
+	}
+
+	checks := store.QueryChecks(Query{Environment: "prod", Application: "flamingo"})
+	if len(checks) != 2 || checks[0].Reason != "HTTP 500" || checks[0].DurationMs != 120 || checks[1].State != "healthy" {
+		t.Errorf("expected both check results, got %+v", checks)
+	}
+}
+
+func TestBoltStore_Prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	now := time.Now()
+
+	store, err := OpenBoltStore(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Append(
+		Record{Time: now.Add(-72 * time.Hour), Environment: "prod", Application: "flamingo", State: "healthy"},
+		Record{Time: now.Add(-48 * time.Hour), Environment: "prod", Application: "flamingo", State: "failed"},
+		Record{Time: now.Add(-48 * time.Hour), Environment: "prod", Application: "akeneo", State: "healthy"},
+		Record{Time: now.Add(-time.Hour), Environment: "prod", Application: "flamingo", State: "healthy"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.AppendChecks(
+		CheckResult{Time: now.Add(-3 * time.Hour), Environment: "prod", Application: "flamingo", State: "failed"},
+		CheckResult{Time: now.Add(-time.Minute), Environment: "prod", Application: "flamingo", State: "healthy"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Close()
+
+	// reopening prunes with the new retention
+	store, err = OpenBoltStore(path, 24*time.Hour, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	records := store.Query(Query{})
+	if len(records) != 3 {
+		t.Fatalf("expected the last record before the retention per app and the newer one, got %+v", records)
+	}
+	if records[0].Application != "akeneo" || records[1].State != "failed" || records[2].State != "healthy" {
+		t.Errorf("unexpected records after pruning: %+v", records)
+	}
+
+	checks := store.QueryChecks(Query{})
+	if len(checks) != 1 || checks[0].State != "healthy" {
+		t.Errorf("expected only the check result within the check retention, got %+v", checks)
+	}
+}
@@ -0,0 +1,280 @@
+package kube
+
+import (
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+)
+
+type (
+	// AvailabilityWindow is a period before now the availability is calculated for
+	AvailabilityWindow struct {
+		Name     string
+		Duration time.Duration
+	}
+
+	// Availability is the uptime of an app (or the apps of a team) within a window, calculated from the recorded check results
+	Availability struct {
+		Window AvailabilityWindow
+		// Measured is the time covered by checks with a known state, Down the part of it the own check was failed or unhealthy
+		Measured, Down time.Duration
+		// Incidents are the periods the app was down, consecutive failed and unhealthy states are one incident
+		Incidents int
+	}
+
+	// AvailabilityReport holds the availability of an app or team for all AvailabilityWindows
+	AvailabilityReport struct {
+		Name    string
+		Team    string
+		Windows []Availability
+	}
+)
+
+// AvailabilityWindows are the windows of the availability reports and metrics
+var AvailabilityWindows = []AvailabilityWindow{
+	{Name: "24h", Duration: 24 * time.Hour},
+	{Name: "7d", Duration: 7 * 24 * time.Hour},
+	{Name: "30d", Duration: 30 * 24 * time.Hour},
+}
+
+const (
+	// availabilityMetricsInterval limits how often the availability metrics are calculated
+	availabilityMetricsInterval = time.Minute
+	// checkResultValidity is the longest time a check result counts for if no other check follows,
+	// longer gaps (failed fetches, times the dashboard was not running) are not measured
+	checkResultValidity = 2 * time.Minute
+)
+
+var (
+	availabilityRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "application_availability_ratio",
+		Help: "Share of the recorded time the application was not failed or unhealthy",
+	}, []string{"application", "team", "environment", "window"})
+
+	availabilityIncidents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "application_incidents",
+		Help: "Number of times the application was failed or unhealthy",
+	}, []string{"application", "team", "environment", "window"})
+
+	availabilityMTTR = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "application_mttr_seconds",
+		Help: "Mean time to recovery of the incidents of the application",
+	}, []string{"application", "team", "environment", "window"})
+
+	teamAvailabilityRatio = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "team_availability_ratio",
+		Help: "Share of the recorded time the applications of the team were not failed or unhealthy",
+	}, []string{"team", "environment", "window"})
+
+	teamAvailabilityIncidents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "team_incidents",
+		Help: "Number of incidents of the applications of the team",
+	}, []string{"team", "environment", "window"})
+
+	teamAvailabilityMTTR = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "team_mttr_seconds",
+		Help: "Mean time to recovery of the incidents of the applications of the team",
+	}, []string{"team", "environment", "window"})
+)
+
+func init() {
+	prometheus.MustRegister(availabilityRatio, availabilityIncidents, availabilityMTTR)
+	prometheus.MustRegister(teamAvailabilityRatio, teamAvailabilityIncidents, teamAvailabilityMTTR)
+}
+
+// Known is false if no check with a known state was recorded in the window
+func (a Availability) Known() bool {
+	return a.Measured > 0
+}
+
+// Ratio returns the share of the measured time the app was up, 1 if nothing was measured
+func (a Availability) Ratio() float64 {
+	if a.Measured <= 0 {
+		return 1
+	}
+	return 1 - float64(a.Down)/float64(a.Measured)
+}
+
+// Percentage returns the Ratio in percent
+func (a Availability) Percentage() float64 {
+	return a.Ratio() * 100
+}
+
+// MTTR returns the mean time to recovery, an ongoing incident counts with its duration so far
+func (a Availability) MTTR() time.Duration {
+	if a.Incidents == 0 {
+		return 0
+	}
+	return a.Down / time.Duration(a.Incidents)
+}
+
+// add sums up the availability of another app of a team
+func (a Availability) add(other Availability) Availability {
+	a.Measured += other.Measured
+	a.Down += other.Down
+	a.Incidents += other.Incidents
+	return a
+}
+
+// CalculateAvailability calculates the availability within the window before now from the check results of a single app ordered by time.
+// Every check result lasts until the next one, for checkResultValidity at most. Unknown and ignored states and the gaps between checks do not count as measured,
+// an incident continues across them.
+func CalculateAvailability(checks []history.CheckResult, window AvailabilityWindow, now time.Time) Availability {
+	availability := Availability{Window: window}
+	from := now.Add(-window.Duration)
+
+	down := false
+	for i, check := range checks {
+		end := now
+		if i+1 < len(checks) {
+			end = checks[i+1].Time
+		}
+		if validUntil := check.Time.Add(checkResultValidity); end.After(validUntil) {
+			end = validUntil
+		}
+		if end.After(now) {
+			end = now
+		}
+		start := check.Time
+		if start.Before(from) {
+			start = from
+		}
+
+		state, _ := StateByName(check.State)
+		if state == State_unknown || state == State_ignored {
+			continue
+		}
+		wasDown := down
+		down = IsBroken(state)
+		if !end.After(start) {
+			continue
+		}
+
+		availability.Measured += end.Sub(start)
+		if down {
+			availability.Down += end.Sub(start)
+			if !wasDown || availability.Incidents == 0 {
+				availability.Incidents++
+			}
+		}
+	}
+
+	return availability
+}
+
+// AvailabilityReports returns the availability of all checked apps, ordered by name, nil without History
+func (stm *StatusFetcher) AvailabilityReports(now time.Time) []AvailabilityReport {
+	stm.mu.RLock()
+	results := maps.Clone(stm.apps)
+	stm.mu.RUnlock()
+
+	return stm.availabilityReports(results, now)
+}
+
+// availabilityReports calculates the availability of the apps of results
+func (stm *StatusFetcher) availabilityReports(results map[string]AppDeploymentInfo, now time.Time) []AvailabilityReport {
+	if stm.History == nil {
+		return nil
+	}
+
+	var longest time.Duration
+	for _, window := range AvailabilityWindows {
+		longest = max(longest, window.Duration)
+	}
+	checksByApp := make(map[string][]history.CheckResult)
+	for _, check := range stm.History.QueryChecks(history.Query{Environment: stm.environment, From: now.Add(-longest - checkResultValidity)}) {
+		checksByApp[check.Application] = append(checksByApp[check.Application], check)
+	}
+
+	reports := make([]AvailabilityReport, 0, len(results))
+	for key, status := range results {
+		report := AvailabilityReport{Name: key, Team: status.VistectureApp.Team}
+		for _, window := range AvailabilityWindows {
+			report.Windows = append(report.Windows, CalculateAvailability(checksByApp[key], window, now))
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Name < reports[j].Name })
+
+	return reports
+}
+
+// TeamAvailabilityReports sums up the availability of the apps per team, ordered by team, apps without team are left out
+func TeamAvailabilityReports(reports []AvailabilityReport) []AvailabilityReport {
+	byTeam := make(map[string]*AvailabilityReport)
+	var teams []string
+	for _, report := range reports {
+		if report.Team == "" {
+			continue
+		}
+
+		team, ok := byTeam[report.Team]
+		if !ok {
+			team = &AvailabilityReport{Name: report.Team, Team: report.Team, Windows: make([]Availability, len(report.Windows))}
+			for i, availability := range report.Windows {
+				team.Windows[i].Window = availability.Window
+			}
+			byTeam[report.Team] = team
+			teams = append(teams, report.Team)
+		}
+		for i, availability := range report.Windows {
+			team.Windows[i] = team.Windows[i].add(availability)
+		}
+	}
+
+	sort.Strings(teams)
+	result := make([]AvailabilityReport, 0, len(teams))
+	for _, team := range teams {
+		result = append(result, *byTeam[team])
+	}
+
+	return result
+}
+
+// setAvailabilityMetrics sets the availability gauges of the apps and teams at most once per availabilityMetricsInterval,
+// the caller must not hold the lock as the History is queried
+func (stm *StatusFetcher) setAvailabilityMetrics(results map[string]AppDeploymentInfo, now time.Time) {
+	if stm.History == nil {
+		return
+	}
+
+	stm.mu.Lock()
+	due := now.Sub(stm.availabilityUpdated) >= availabilityMetricsInterval
+	if due {
+		stm.availabilityUpdated = now
+	}
+	stm.mu.Unlock()
+	if !due {
+		return
+	}
+
+	reports := stm.availabilityReports(results, now)
+	for _, report := range reports {
+		status := results[report.Name]
+		for _, availability := range report.Windows {
+			if !availability.Known() {
+				continue
+			}
+			labels := prometheus.Labels{"application": status.Name, "team": report.Team, "environment": stm.environment, "window": availability.Window.Name}
+			availabilityRatio.With(labels).Set(availability.Ratio())
+			availabilityIncidents.With(labels).Set(float64(availability.Incidents))
+			availabilityMTTR.With(labels).Set(availability.MTTR().Seconds())
+		}
+	}
+
+	for _, report := range TeamAvailabilityReports(reports) {
+		for _, availability := range report.Windows {
+			if !availability.Known() {
+				continue
+			}
+			labels := prometheus.Labels{"team": report.Name, "environment": stm.environment, "window": availability.Window.Name}
+			teamAvailabilityRatio.With(labels).Set(availability.Ratio())
+			teamAvailabilityIncidents.With(labels).Set(float64(availability.Incidents))
+			teamAvailabilityMTTR.With(labels).Set(availability.MTTR().Seconds())
+		}
+	}
+}
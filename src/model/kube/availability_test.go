@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+)
+
+func TestCalculateAvailability(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	var checks []history.CheckResult
+	// add adds a check result per minute, from and to are minutes before now
+	add := func(from, to int, state string) {
+		for minute := from; minute < to; minute++ {
+			checks = append(checks, history.CheckResult{Time: now.Add(time.Duration(minute) * time.Minute), State: state})
+		}
+	}
+	add(-180, -120, "healthy")
+	add(-120, -90, "failed")
+	// the dashboard was not running for half an hour
+	add(-60, -50, "failed")
+	add(-50, -30, "healthy")
+	add(-30, -29, "unknown")
+	add(-29, -20, "healthy")
+	add(-20, -19, "unhealthy")
+	add(-19, 0, "healthy")
+
+	day := CalculateAvailability(checks, AvailabilityWindows[0], now)
+	// the last failed check before the gap counts for checkResultValidity, the failed checks before and after the gap are one incident
+	if day.Measured != 150*time.Minute || day.Down != 42*time.Minute || day.Incidents != 2 {
+		t.Errorf("unexpected availability of the last 24h: %+v", day)
+	}
+	if day.MTTR() != 21*time.Minute {
+		t.Errorf("expected a MTTR of 21m, got %v", day.MTTR())
+	}
+
+	hour := CalculateAvailability(checks, AvailabilityWindow{Name: "1h", Duration: time.Hour}, now)
+	if hour.Measured != 59*time.Minute || hour.Down != 11*time.Minute || hour.Incidents != 2 {
+		t.Errorf("unexpected availability of the last hour: %+v", hour)
+	}
+	if percentage := hour.Percentage(); percentage < 81.3 || percentage > 81.4 {
+		t.Errorf("expected an availability of 81.3%%, got %v", percentage)
+	}
+
+	empty := CalculateAvailability(nil, AvailabilityWindows[0], now)
+	if empty.Known() || empty.Ratio() != 1 || empty.MTTR() != 0 {
+		t.Errorf("expected an unknown availability without check results, got %+v", empty)
+	}
+}
+
+func TestTeamAvailabilityReports(t *testing.T) {
+	window := AvailabilityWindows[0]
+	reports := []AvailabilityReport{
+		{Name: "akeneo", Team: "Team 2", Windows: []Availability{{Window: window, Measured: 10 * time.Hour, Down: time.Hour, Incidents: 1}}},
+		{Name: "flamingo", Team: "Team 1", Windows: []Availability{{Window: window, Measured: 10 * time.Hour}}},
+		{Name: "keycloak", Team: "Team 1", Windows: []Availability{{Window: window, Measured: 10 * time.Hour, Down: 4 * time.Hour, Incidents: 2}}},
+		{Name: "unowned", Windows: []Availability{{Window: window, Measured: time.Hour, Down: time.Hour, Incidents: 1}}},
+	}
+
+	teams := TeamAvailabilityReports(reports)
+	if len(teams) != 2 || teams[0].Name != "Team 1" || teams[1].Name != "Team 2" {
+		t.Fatalf("expected the reports of Team 1 and Team 2, got %+v", teams)
+	}
+	if team := teams[0].Windows[0]; team.Ratio() != 0.8 || team.Incidents != 2 || team.MTTR() != 2*time.Hour || team.Window != window {
+		t.Errorf("unexpected availability of Team 1: %+v", team)
+	}
+}
@@ -0,0 +1,51 @@
+package kube
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+type (
+	// cronSchedule is the schedule of a CronJob in the time zone of the CronJob.
+	// It is parsed like the kubernetes CronJob controller does ("minute hour day-of-month month day-of-week" or a descriptor like "@hourly").
+	cronSchedule struct {
+		schedule cron.Schedule
+		location *time.Location
+	}
+)
+
+// parseCronSchedule parses a cron expression, an empty timeZone uses UTC like the kubernetes controller manager usually does.
+// A "CRON_TZ=" or "TZ=" prefix of the expression overrides the timeZone.
+func parseCronSchedule(expression string, timeZone string) (*cronSchedule, error) {
+	location := time.UTC
+	if timeZone != "" {
+		var err error
+		location, err = time.LoadLocation(timeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q: %w", timeZone, err)
+		}
+	}
+
+	schedule, err := cron.ParseStandard(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cronSchedule{schedule: schedule, location: location}, nil
+}
+
+// next returns the first activation after t, the zero time if there is none within the next years (e.g. "0 0 30 2 *")
+func (s *cronSchedule) next(t time.Time) time.Time {
+	return s.schedule.Next(t.In(s.location))
+}
+
+// nthActivation returns the n-th activation after t
+func (s *cronSchedule) nthActivation(t time.Time, n int) time.Time {
+	for i := 0; i < n && !t.IsZero(); i++ {
+		t = s.next(t)
+	}
+
+	return t
+}
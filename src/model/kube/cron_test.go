@@ -0,0 +1,55 @@
+package kube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronSchedule_Next(t *testing.T) {
+	// a wednesday
+	from := time.Date(2024, 5, 15, 10, 7, 30, 0, time.UTC)
+
+	testCases := []struct {
+		schedule, timeZone string
+		expected           time.Time
+	}{
+		{schedule: "*/15 * * * *", expected: time.Date(2024, 5, 15, 10, 15, 0, 0, time.UTC)},
+		{schedule: "@hourly", expected: time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{schedule: "30 2 * * *", expected: time.Date(2024, 5, 16, 2, 30, 0, 0, time.UTC)},
+		{schedule: "0 9 * * mon-fri", expected: time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 * * sun", expected: time.Date(2024, 5, 19, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 1 * *", expected: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{schedule: "0 0 20 * 4", expected: time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC)},
+		// a step restricts the day of month, like the kubernetes controller does
+		{schedule: "0 0 */10 * 5", expected: time.Date(2024, 5, 17, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 29 feb *", expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{schedule: "0 8 * * *", timeZone: "Europe/Berlin", expected: time.Date(2024, 5, 16, 6, 0, 0, 0, time.UTC)},
+		{schedule: "CRON_TZ=Europe/Berlin 0 13 * * *", expected: time.Date(2024, 5, 15, 11, 0, 0, 0, time.UTC)},
+		{schedule: "0 0 30 2 *", expected: time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		schedule, err := parseCronSchedule(tc.schedule, tc.timeZone)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tc.schedule, err)
+			continue
+		}
+
+		if next := schedule.next(from); !next.Equal(tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.schedule, tc.expected, next)
+		}
+	}
+}
+
+func TestParseCronSchedule_Invalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "0 0 * foo *"} {
+		if _, err := parseCronSchedule(expression, ""); err == nil {
+			t.Errorf("%q: expected an error", expression)
+		}
+	}
+
+	if _, err := parseCronSchedule("0 * * * *", "Nowhere/Unknown"); err == nil {
+		t.Error("expected an error for an unknown time zone")
+	}
+}
@@ -0,0 +1,139 @@
+package kube
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	v1Batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type (
+	// Event is a kubernetes Warning event of an app, its ReplicaSets or pods
+	Event struct {
+		Time    time.Time
+		Reason  string
+		Message string
+		// Object is the kind and name of the involved object, e.g. "Pod/flamingo-5f6d8-xyz12"
+		Object string
+		Count  int32
+	}
+)
+
+// maxEventsPerApp limits the events attached to an app to the most recent ones
+const maxEventsPerApp = 10
+
+// groupEventsByWorkload indexes the events by namespace and name of the workload (deployment, stateful set, daemon set, job or cron job) they are about.
+// Events of pods, replica sets and jobs are resolved to their workload, events of already deleted pods by their generated name.
+// The events are ordered by time, the most recent first.
+func groupEventsByWorkload(events []*v1.Event, pods map[string]*v1.Pod, replicaSets map[string]*apps.ReplicaSet, jobs map[string]*v1Batch.Job) map[string][]Event {
+	eventIndex := make(map[string][]Event)
+	for _, event := range events {
+		object := event.InvolvedObject
+		namespace := object.Namespace
+		if namespace == "" {
+			namespace = event.Namespace
+		}
+
+		var workload string
+		switch object.Kind {
+		case "Pod":
+			if pod, ok := pods[ResourceKey(namespace, object.Name)]; ok {
+				workload = podWorkload(pod, replicaSets)
+				if owner := metav1.GetControllerOf(pod); owner != nil && owner.Kind == "Job" {
+					workload = jobWorkload(namespace, owner.Name, jobs)
+				}
+			} else {
+				workload = deletedPodWorkload(namespace, object.Name, replicaSets, jobs)
+			}
+		case "ReplicaSet":
+			workload = replicaSetWorkload(namespace, object.Name, replicaSets)
+		case "Job":
+			workload = jobWorkload(namespace, object.Name, jobs)
+		case "Deployment", "StatefulSet", "DaemonSet", "CronJob":
+			workload = object.Name
+		}
+		if workload == "" {
+			continue
+		}
+
+		key := ResourceKey(namespace, workload)
+		eventIndex[key] = append(eventIndex[key], Event{
+			Time:    eventTime(event),
+			Reason:  event.Reason,
+			Message: event.Message,
+			Object:  object.Kind + "/" + object.Name,
+			Count:   eventCount(event),
+		})
+	}
+
+	for key, workloadEvents := range eventIndex {
+		sort.Slice(workloadEvents, func(i, j int) bool {
+			return workloadEvents[i].Time.After(workloadEvents[j].Time)
+		})
+		if len(workloadEvents) > maxEventsPerApp {
+			workloadEvents = workloadEvents[:maxEventsPerApp]
+		}
+		eventIndex[key] = workloadEvents
+	}
+
+	return eventIndex
+}
+
+// deletedPodWorkload guesses the workload of a pod that does not exist anymore by its generated name,
+// e.g. "flamingo-5f6d8-xyz12" of the replica set "flamingo-5f6d8", "export-28561020-abcde" of the job "export-28561020"
+// or "elasticsearch-0" of the stateful set "elasticsearch"
+func deletedPodWorkload(namespace, podName string, replicaSets map[string]*apps.ReplicaSet, jobs map[string]*v1Batch.Job) string {
+	i := strings.LastIndex(podName, "-")
+	if i < 1 {
+		return ""
+	}
+
+	if workload := replicaSetWorkload(namespace, podName[:i], replicaSets); workload != "" {
+		return workload
+	}
+	if _, ok := jobs[ResourceKey(namespace, podName[:i])]; ok {
+		return jobWorkload(namespace, podName[:i], jobs)
+	}
+
+	return podName[:i]
+}
+
+// jobWorkload returns the name of the app of a job, which is the CronJob owning it (see jobAppName)
+func jobWorkload(namespace, name string, jobs map[string]*v1Batch.Job) string {
+	if job, ok := jobs[ResourceKey(namespace, name)]; ok {
+		return jobAppName(job)
+	}
+
+	return jobAppName(&v1Batch.Job{ObjectMeta: metav1.ObjectMeta{Name: name}})
+}
+
+// eventTime returns the time the event was seen last
+func eventTime(event *v1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+
+	return event.CreationTimestamp.Time
+}
+
+// eventCount returns how often the event occurred
+func eventCount(event *v1.Event) int32 {
+	if event.Series != nil && event.Series.Count > 0 {
+		return event.Series.Count
+	}
+	if event.Count > 0 {
+		return event.Count
+	}
+	return 1
+}
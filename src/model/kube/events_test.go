@@ -0,0 +1,81 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGroupEventsByWorkload(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	controller := true
+	event := func(kind, name, reason string, age time.Duration) *v1.Event {
+		return &v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "shop"},
+			InvolvedObject: v1.ObjectReference{Kind: kind, Name: name},
+			Reason:         reason,
+			LastTimestamp:  metav1.NewTime(now.Add(-age)),
+		}
+	}
+
+	replicaSets := map[string]*apps.ReplicaSet{
+		"shop/flamingo-5f6d8": {ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "flamingo"}}}},
+	}
+	pods := map[string]*v1.Pod{
+		"shop/flamingo-5f6d8-a": {ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "shop",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "flamingo-5f6d8", Controller: &controller}},
+		}},
+	}
+	jobs := map[string]*batch.Job{
+		"shop/export-28561020": {ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "export"}}}},
+	}
+	pods["shop/export-28561020-x"] = &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "shop",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Job", Name: "export-28561020", Controller: &controller}},
+	}}
+	events := []*v1.Event{
+		event("Pod", "flamingo-5f6d8-a", "BackOff", time.Minute),
+		// the runs of the export CronJob
+		event("Pod", "export-28561020-x", "BackOff", 4*time.Minute),
+		event("Pod", "export-28561020-y", "FailedScheduling", 6*time.Minute),
+		event("Job", "export-28561020", "BackoffLimitExceeded", 7*time.Minute),
+		event("Job", "export-28560960", "DeadlineExceeded", 60*time.Minute),
+		event("ReplicaSet", "flamingo-5f6d8", "FailedCreate", 10*time.Minute),
+		event("Deployment", "flamingo", "ProgressDeadlineExceeded", 5*time.Minute),
+		// the pod is already deleted
+		event("Pod", "flamingo-5f6d8-b", "OOMKilling", 2*time.Minute),
+		event("Pod", "elasticsearch-0", "FailedMount", 3*time.Minute),
+		event("Node", "node-1", "NodeNotReady", time.Minute),
+	}
+
+	index := groupEventsByWorkload(events, pods, replicaSets, jobs)
+
+	var reasons []string
+	for _, e := range index["shop/flamingo"] {
+		reasons = append(reasons, e.Reason)
+	}
+	expected := "BackOff OOMKilling ProgressDeadlineExceeded FailedCreate"
+	if got := strings.Join(reasons, " "); got != expected {
+		t.Errorf("expected events %q ordered by time, got %q", expected, got)
+	}
+	if len(index["shop/elasticsearch"]) != 1 {
+		t.Errorf("expected the event of the stateful set pod, got %v", index["shop/elasticsearch"])
+	}
+	reasons = nil
+	for _, e := range index["shop/export"] {
+		reasons = append(reasons, e.Reason)
+	}
+	expected = "BackOff FailedScheduling BackoffLimitExceeded DeadlineExceeded"
+	if got := strings.Join(reasons, " "); got != expected {
+		t.Errorf("expected the events of the CronJob runs %q, got %q", expected, got)
+	}
+	if len(index) != 3 {
+		t.Errorf("expected events of 3 workloads, got %d", len(index))
+	}
+}
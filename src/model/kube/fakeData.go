@@ -1,6 +1,8 @@
 package kube
 
 import (
+	"time"
+
 	appsV1 "k8s.io/api/apps/v1"
 	batchV1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
@@ -16,19 +18,34 @@ type (
 	}
 )
 
+// demoNamespace is the namespace of all fake resources
+const demoNamespace = "default"
+
 var _ KubeInfoServiceInterface = &DemoService{}
 
+// NewDemoService creates a DemoService whose fake "localhost" service points to the fake health check port
+func NewDemoService(fakeHealthcheckPort int32) *DemoService {
+	return &DemoService{fakeHealthcheckPort: fakeHealthcheckPort}
+}
+
+// GetDefaultNamespace returns the namespace of the fake resources
+func (d *DemoService) GetDefaultNamespace() (string, error) {
+	return demoNamespace, nil
+}
+
 // GetKubernetesDeployments returns fake deployments
 func (d *DemoService) GetKubernetesDeployments() (map[string]appsV1.Deployment, error) {
 	deployments := map[string]appsV1.Deployment{
-		"service": {
+		ResourceKey(demoNamespace, "service"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "service",
+				Name:      "service",
+				Namespace: demoNamespace,
 				Labels: map[string]string{
 					"chart": "service-1.0.1",
 				},
 			},
 			Spec: appsV1.DeploymentSpec{
+				Replicas: int32Ptr(2),
 				Template: v1.PodTemplateSpec{
 					Spec: v1.PodSpec{
 						Containers: []v1.Container{
@@ -46,14 +63,16 @@ func (d *DemoService) GetKubernetesDeployments() (map[string]appsV1.Deployment,
 				},
 			},
 		},
-		"flamingo": {
+		ResourceKey(demoNamespace, "flamingo"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "flamingo",
+				Name:      "flamingo",
+				Namespace: demoNamespace,
 				Labels: map[string]string{
 					"chart": "flamingo-1.0.1",
 				},
 			},
 			Spec: appsV1.DeploymentSpec{
+				Replicas: int32Ptr(5),
 				Template: v1.PodTemplateSpec{
 					Spec: v1.PodSpec{
 						Containers: []v1.Container{
@@ -64,6 +83,7 @@ func (d *DemoService) GetKubernetesDeployments() (map[string]appsV1.Deployment,
 			},
 			Status: appsV1.DeploymentStatus{
 				AvailableReplicas:  3,
+				ReadyReplicas:      3,
 				Replicas:           5,
 				ObservedGeneration: 132,
 				Conditions: []appsV1.DeploymentCondition{
@@ -71,15 +91,17 @@ func (d *DemoService) GetKubernetesDeployments() (map[string]appsV1.Deployment,
 				},
 			},
 		},
-		"akeneo": {
+		ResourceKey(demoNamespace, "akeneo"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "akeneo",
+				Name:      "akeneo",
+				Namespace: demoNamespace,
 				Labels: map[string]string{
 					"chart":           "akeneo-1.2.3",
 					"helm.sh/version": "1.2.3",
 				},
 			},
 			Spec: appsV1.DeploymentSpec{
+				Replicas: int32Ptr(1),
 				Template: v1.PodTemplateSpec{
 					Spec: v1.PodSpec{
 						Containers: []v1.Container{
@@ -97,11 +119,13 @@ func (d *DemoService) GetKubernetesDeployments() (map[string]appsV1.Deployment,
 				},
 			},
 		},
-		"keycloak": {
+		ResourceKey(demoNamespace, "keycloak"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "keycloak",
+				Name:      "keycloak",
+				Namespace: demoNamespace,
 			},
 			Spec: appsV1.DeploymentSpec{
+				Replicas: int32Ptr(2),
 				Template: v1.PodTemplateSpec{
 					Spec: v1.PodSpec{
 						Containers: []v1.Container{
@@ -125,11 +149,77 @@ func (d *DemoService) GetKubernetesDeployments() (map[string]appsV1.Deployment,
 	return deployments, nil
 }
 
+// GetStatefulSets returns fake stateful sets
+func (d *DemoService) GetStatefulSets() (map[string]appsV1.StatefulSet, error) {
+	statefulSets := map[string]appsV1.StatefulSet{
+		ResourceKey(demoNamespace, "elasticsearch"): {
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      "elasticsearch",
+				Namespace: demoNamespace,
+				Labels: map[string]string{
+					"chart": "elasticsearch-8.5.1",
+				},
+			},
+			Spec: appsV1.StatefulSetSpec{
+				Replicas: int32Ptr(3),
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{Image: "elasticsearch:8.5.1"},
+						},
+					},
+				},
+			},
+			Status: appsV1.StatefulSetStatus{
+				Replicas:           3,
+				ReadyReplicas:      3,
+				AvailableReplicas:  3,
+				ObservedGeneration: 4,
+			},
+		},
+	}
+
+	return statefulSets, nil
+}
+
+// GetDaemonSets returns fake daemon sets
+func (d *DemoService) GetDaemonSets() (map[string]appsV1.DaemonSet, error) {
+	daemonSets := map[string]appsV1.DaemonSet{
+		ResourceKey(demoNamespace, "node-exporter"): {
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      "node-exporter",
+				Namespace: demoNamespace,
+			},
+			Spec: appsV1.DaemonSetSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{Image: "prom/node-exporter:v1.7.0"},
+						},
+					},
+				},
+			},
+			Status: appsV1.DaemonSetStatus{
+				DesiredNumberScheduled: 4,
+				CurrentNumberScheduled: 4,
+				NumberReady:            4,
+				NumberAvailable:        4,
+				ObservedGeneration:     2,
+			},
+		},
+	}
+
+	return daemonSets, nil
+}
+
 // GetIngressesByService returns fake services
 func (d *DemoService) GetIngressesByService() (map[string][]K8sIngressInfo, error) {
 	ingressList := &networkingV1.IngressList{
 		Items: []networkingV1.Ingress{
 			{
+				ObjectMeta: metaV1.ObjectMeta{
+					Namespace: demoNamespace,
+				},
 				Spec: networkingV1.IngressSpec{
 					Rules: []networkingV1.IngressRule{
 						{
@@ -146,6 +236,9 @@ func (d *DemoService) GetIngressesByService() (map[string][]K8sIngressInfo, erro
 				},
 			},
 			{
+				ObjectMeta: metaV1.ObjectMeta{
+					Namespace: demoNamespace,
+				},
 				Spec: networkingV1.IngressSpec{
 					Rules: []networkingV1.IngressRule{
 						{
@@ -162,6 +255,9 @@ func (d *DemoService) GetIngressesByService() (map[string][]K8sIngressInfo, erro
 				},
 			},
 			{
+				ObjectMeta: metaV1.ObjectMeta{
+					Namespace: demoNamespace,
+				},
 				Spec: networkingV1.IngressSpec{
 					Rules: []networkingV1.IngressRule{
 						{
@@ -179,7 +275,8 @@ func (d *DemoService) GetIngressesByService() (map[string][]K8sIngressInfo, erro
 			},
 			{
 				ObjectMeta: metaV1.ObjectMeta{
-					Name: "keycloak",
+					Name:      "keycloak",
+					Namespace: demoNamespace,
 				},
 				Spec: networkingV1.IngressSpec{
 					Rules: []networkingV1.IngressRule{
@@ -205,24 +302,27 @@ func (d *DemoService) GetIngressesByService() (map[string][]K8sIngressInfo, erro
 // GetServices returns fake services
 func (d *DemoService) GetServices() (map[string]v1.Service, error) {
 	services := map[string]v1.Service{
-		"localhost": {
+		ResourceKey(demoNamespace, "localhost"): {
 			Spec: v1.ServiceSpec{
 				Ports: []v1.ServicePort{
 					{Port: d.fakeHealthcheckPort, TargetPort: intstr.FromInt(8080)},
 				},
 			},
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "localhost",
+				Name:      "localhost",
+				Namespace: demoNamespace,
 			},
 		},
-		"keycloak": {
+		ResourceKey(demoNamespace, "keycloak"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "keycloak",
+				Name:      "keycloak",
+				Namespace: demoNamespace,
 			},
 		},
-		"flamingo": {
+		ResourceKey(demoNamespace, "flamingo"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "flamingo",
+				Name:      "flamingo",
+				Namespace: demoNamespace,
 			},
 			Spec: v1.ServiceSpec{
 				Ports: []v1.ServicePort{
@@ -230,9 +330,10 @@ func (d *DemoService) GetServices() (map[string]v1.Service, error) {
 				},
 			},
 		},
-		"akeneo": {
+		ResourceKey(demoNamespace, "akeneo"): {
 			ObjectMeta: metaV1.ObjectMeta{
-				Name: "akeneo",
+				Name:      "akeneo",
+				Namespace: demoNamespace,
 			},
 		},
 	}
@@ -247,5 +348,144 @@ func (d *DemoService) GetConfigMaps() (map[string]v1.ConfigMap, error) {
 
 // GetJobsByApp returns jobs matching app names
 func (d *DemoService) GetJobsByApp() (map[string][]batchV1.Job, error) {
-	return nil, nil
+	lastRun := metaV1.NewTime(time.Now().Truncate(time.Hour))
+	jobs := []*batchV1.Job{
+		{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:            "export-29000000",
+				Namespace:       demoNamespace,
+				OwnerReferences: []metaV1.OwnerReference{{Kind: "CronJob", Name: "export"}},
+			},
+			Status: batchV1.JobStatus{
+				Succeeded:      1,
+				CompletionTime: &lastRun,
+			},
+		},
+	}
+
+	return groupJobsByApp(jobs), nil
+}
+
+// GetCronJobs returns fake cron jobs
+func (d *DemoService) GetCronJobs() (map[string]batchV1.CronJob, error) {
+	lastRun := metaV1.NewTime(time.Now().Truncate(time.Hour))
+	cronJobs := map[string]batchV1.CronJob{
+		ResourceKey(demoNamespace, "export"): {
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      "export",
+				Namespace: demoNamespace,
+			},
+			Spec: batchV1.CronJobSpec{
+				Schedule: "0 * * * *",
+				JobTemplate: batchV1.JobTemplateSpec{
+					Spec: batchV1.JobSpec{
+						Template: v1.PodTemplateSpec{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{Image: "export:v2.1.0"},
+								},
+							},
+						},
+					},
+				},
+			},
+			Status: batchV1.CronJobStatus{
+				LastScheduleTime:   &lastRun,
+				LastSuccessfulTime: &lastRun,
+			},
+		},
+	}
+
+	return cronJobs, nil
+}
+
+// GetPodsByWorkload returns fake pods, one of the service pods got OOMKilled recently and one flamingo pod is crash looping
+func (d *DemoService) GetPodsByWorkload() (map[string][]v1.Pod, error) {
+	controller := true
+	oomKilledAt := metaV1.NewTime(time.Now().Add(-10 * time.Minute))
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:            "service-7d4b9c-abcde",
+				Namespace:       demoNamespace,
+				OwnerReferences: []metaV1.OwnerReference{{Kind: "ReplicaSet", Name: "service-7d4b9c", Controller: &controller}},
+			},
+			Status: v1.PodStatus{
+				Phase:      v1.PodRunning,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:                 "service",
+						RestartCount:         1,
+						State:                v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+						LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: oomKilledAt}},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:            "flamingo-5f6d8-xyz12",
+				Namespace:       demoNamespace,
+				OwnerReferences: []metaV1.OwnerReference{{Kind: "ReplicaSet", Name: "flamingo-5f6d8", Controller: &controller}},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:         "flamingo",
+						RestartCount: 12,
+						State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					},
+				},
+			},
+		},
+	}
+
+	replicaSets := map[string]*appsV1.ReplicaSet{
+		ResourceKey(demoNamespace, "service-7d4b9c"): {
+			ObjectMeta: metaV1.ObjectMeta{Name: "service-7d4b9c", Namespace: demoNamespace, OwnerReferences: []metaV1.OwnerReference{{Kind: "Deployment", Name: "service"}}},
+		},
+		ResourceKey(demoNamespace, "flamingo-5f6d8"): {
+			ObjectMeta: metaV1.ObjectMeta{Name: "flamingo-5f6d8", Namespace: demoNamespace, OwnerReferences: []metaV1.OwnerReference{{Kind: "Deployment", Name: "flamingo"}}},
+		},
+	}
+
+	return groupPodsByWorkload(pods, replicaSets), nil
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+// GetWarningEventsByWorkload returns fake events for the crash looping flamingo pod
+func (d *DemoService) GetWarningEventsByWorkload() (map[string][]Event, error) {
+	now := time.Now()
+	events := map[string][]Event{
+		ResourceKey(demoNamespace, "flamingo"): {
+			{
+				Time:    now.Add(-time.Minute),
+				Reason:  "BackOff",
+				Message: "Back-off restarting failed container flamingo in pod flamingo-5f6d8-xyz12",
+				Object:  "Pod/flamingo-5f6d8-xyz12",
+				Count:   42,
+			},
+			{
+				Time:    now.Add(-20 * time.Minute),
+				Reason:  "Unhealthy",
+				Message: "Readiness probe failed: HTTP probe failed with statuscode: 503",
+				Object:  "Pod/flamingo-5f6d8-xyz12",
+				Count:   12,
+			},
+			{
+				Time:    now.Add(-25 * time.Minute),
+				Reason:  "FailedCreate",
+				Message: "Error creating: pods \"flamingo-5f6d8-\" is forbidden: exceeded quota: compute-resources",
+				Object:  "ReplicaSet/flamingo-5f6d8",
+				Count:   3,
+			},
+		},
+	}
+
+	return events, nil
 }
@@ -0,0 +1,324 @@
+package kube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type (
+	// HealthChecker checks the health of an app, it is selected by the healthCheckType property
+	HealthChecker interface {
+		Check(ctx context.Context, target HealthCheckTarget) HealthCheckResult
+	}
+
+	// HealthCheckTarget is the address of the app to check
+	HealthCheckTarget struct {
+		// Address is host:port of the service, or the host of an ingress
+		Address string
+		// Scheme of HTTP checks, http for services and https for ingresses
+		Scheme string
+		// BasePath is put in front of the path of HTTP checks, e.g. the path of the service proxy of the API server
+		BasePath string
+		// Path of HTTP checks, the response is parsed as healthcheck response if set
+		Path string
+	}
+
+	// HealthCheckResult is the outcome of a check
+	HealthCheckResult struct {
+		Healthy bool
+		// Type is the kind of check done, see HealthCheckType_*
+		Type string
+		// Cause categorizes why the check failed, see HealthCheckCause_*, Reason describes it
+		Cause   string
+		Reason  string
+		Latency time.Duration
+		// Services are the dependencies reported by the app
+		Services []HealthCheckService
+	}
+
+	// HTTPHealthChecker calls the healthcheck path, without path and expected status any response below 501 is healthy
+	HTTPHealthChecker struct {
+		Client *http.Client
+		// Format of the healthcheck response, see HealthCheckFormat_*, detected if empty
+		Format string
+		// Method and Header of the request, GET if empty
+		Method string
+		Header http.Header
+		Expect HTTPExpectations
+	}
+
+	// TCPHealthChecker is healthy if a connection can be established, e.g. for databases
+	TCPHealthChecker struct {
+		Dialer net.Dialer
+	}
+
+	// appHealthCheck is the health check configured by the properties of an app, err is set if they are invalid
+	appHealthCheck struct {
+		checker HealthChecker
+		// publicChecker calls the ingress if k8sHealthCheckThroughIngress is set
+		publicChecker *HTTPHealthChecker
+		maxLatency    time.Duration
+		err           error
+	}
+)
+
+const (
+	// values of the healthCheckType property
+	healthCheckTypeHTTP = "http"
+	healthCheckTypeTCP  = "tcp"
+	healthCheckTypeGRPC = "grpc"
+	// healthCheckTypeExecFreeTCP is an alias of tcp on purpose: the tcp check dials the service from the dashboard
+	// and never executes a command in the pod, so it is exec-free already
+	healthCheckTypeExecFreeTCP = "exec-free-tcp"
+
+	HealthCheckCause_Connection  = "connection"
+	HealthCheckCause_Timeout     = "timeout"
+	HealthCheckCause_Status      = "status"
+	HealthCheckCause_Format      = "format"
+	HealthCheckCause_Dependency  = "dependency"
+	HealthCheckCause_Expectation = "expectation"
+
+	// values of the check label of the duration metric, the check of the service or through the ingress
+	healthCheckPath_Internal = "internal"
+	healthCheckPath_Ingress  = "ingress"
+
+	// healthCheckTimeout limits the time the check of an app may take if CheckTimeout is not set
+	healthCheckTimeout = 15 * time.Second
+)
+
+var (
+	_ HealthChecker = new(HTTPHealthChecker)
+	_ HealthChecker = new(TCPHealthChecker)
+)
+
+// newHealthChecker returns the checker configured by the healthCheckType property of the app, http by default
+func newHealthChecker(app *vistectureCore.Application) (HealthChecker, error) {
+	switch checkType := app.Properties["healthCheckType"]; checkType {
+	case "", healthCheckTypeHTTP:
+		return newHTTPHealthChecker(app.Properties)
+	case healthCheckTypeTCP, healthCheckTypeExecFreeTCP:
+		return new(TCPHealthChecker), nil
+	case healthCheckTypeGRPC:
+		return &GRPCHealthChecker{Service: app.Properties["healthCheckGrpcService"]}, nil
+	default:
+		return nil, fmt.Errorf("unsupported healthCheckType %v", checkType)
+	}
+}
+
+// newAppHealthChecks builds the health checks of the apps once, they are kept as long as the project is loaded
+func newAppHealthChecks(apps []*vistectureCore.Application) map[string]*appHealthCheck {
+	checks := make(map[string]*appHealthCheck, len(apps))
+	for _, app := range apps {
+		checks[app.Name] = newAppHealthCheck(app)
+	}
+	return checks
+}
+
+// newAppHealthCheck builds the checkers configured by the properties of the app
+func newAppHealthCheck(app *vistectureCore.Application) *appHealthCheck {
+	check := new(appHealthCheck)
+
+	var checkerErr, publicCheckerErr, maxLatencyErr error
+	check.checker, checkerErr = newHealthChecker(app)
+	if _, ok := app.Properties["k8sHealthCheckThroughIngress"]; ok {
+		// the ingress is called via HTTP with the expectations of the app, whatever the type of its check
+		if httpChecker, isHTTP := check.checker.(*HTTPHealthChecker); isHTTP {
+			check.publicChecker = httpChecker
+		} else {
+			check.publicChecker, publicCheckerErr = newHTTPHealthChecker(app.Properties)
+		}
+	}
+	check.maxLatency, maxLatencyErr = healthCheckMaxLatency(app.Properties)
+	check.err = errors.Join(checkerErr, publicCheckerErr, maxLatencyErr)
+
+	return check
+}
+
+// newHTTPHealthChecker returns the HTTP checker configured by the properties of an app
+func newHTTPHealthChecker(properties map[string]string) (*HTTPHealthChecker, error) {
+	format := properties["healthCheckFormat"]
+	if !validHealthCheckFormat(format) {
+		return nil, fmt.Errorf("unsupported healthCheckFormat %v", format)
+	}
+
+	expect, err := newHTTPExpectations(properties)
+	if err != nil {
+		return nil, err
+	}
+
+	method := strings.ToUpper(properties["healthCheckMethod"])
+	if method != "" && method != http.MethodGet && method != http.MethodHead && method != http.MethodPost {
+		return nil, fmt.Errorf("unsupported healthCheckMethod %v", method)
+	}
+
+	var header http.Header
+	if headers := properties["healthCheckRequestHeaders"]; headers != "" {
+		header = parseHeaderLines(headers)
+	}
+
+	return &HTTPHealthChecker{Client: httpClient, Format: format, Method: method, Header: header, Expect: expect}, nil
+}
+
+// Check calls the healthcheck path of the target, or its root as simple check without path
+func (c *HTTPHealthChecker) Check(ctx context.Context, target HealthCheckTarget) HealthCheckResult {
+	checkUrl := target.Scheme + "://" + target.Address + target.BasePath + target.Path
+	result := HealthCheckResult{Type: HealthCheckType_NotCheckedYet}
+
+	method := c.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, reqErr := http.NewRequestWithContext(ctx, method, checkUrl, nil)
+	if reqErr != nil {
+		return result.failed(HealthCheckCause_Connection, reqErr.Error())
+	}
+
+	req.Header.Set("User-Agent", healthCheckUserAgent)
+	for name, values := range c.Header {
+		req.Header[name] = values
+	}
+	if host := c.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	start := time.Now()
+	r, httpErr := c.Client.Do(req)
+
+	if httpErr != nil {
+		result.Latency = time.Since(start)
+		return result.failed(connectionCause(httpErr), httpErr.Error())
+	}
+	defer r.Body.Close()
+
+	var responseBody []byte
+	if target.Path != "" || c.Expect.needsBody() {
+		var bodyErr error
+		responseBody, bodyErr = io.ReadAll(r.Body)
+		if bodyErr != nil {
+			result.Latency = time.Since(start)
+			return result.failed(connectionCause(bodyErr), "Could not read from HealthcheckPath")
+		}
+	}
+	result.Latency = time.Since(start)
+
+	statusCode := r.StatusCode
+	if target.Path != "" {
+		result.Type = HealthCheckType_HealthCheck
+	} else {
+		result.Type = HealthCheckType_SimpleCheck
+	}
+
+	if len(c.Expect.Status) > 0 && !c.Expect.statusAccepted(statusCode) {
+		return result.failed(HealthCheckCause_Status, fmt.Sprintf("Unexpected status %v from %v", statusCode, checkUrl))
+	}
+	if err := c.Expect.check(r, responseBody); err != nil {
+		return result.failed(HealthCheckCause_Expectation, fmt.Sprintf("Unexpected response from %v: %v", checkUrl, err))
+	}
+
+	if target.Path != "" {
+		up, services, formatErr := parseHealthCheckResponse(c.Format, r.Header.Get("Content-Type"), responseBody)
+		// Check if Response is valid
+		if formatErr != nil {
+			return result.failed(HealthCheckCause_Format, fmt.Sprintf("HealthcheckPath Format Error from %s: %v", checkUrl, formatErr))
+		}
+
+		result.Services = services
+		statusText := fmt.Sprintf("Status %v for %v ", statusCode, checkUrl)
+		result.Healthy = up
+		if !up {
+			statusText += "reports to be down \n"
+			result.Cause = HealthCheckCause_Status
+		}
+
+		for _, service := range services {
+			if !service.Alive {
+				statusText += fmt.Sprintf("%v (%v) \n", service.Name, service.Details)
+				result.Healthy = false
+				result.Cause = HealthCheckCause_Dependency
+			}
+		}
+
+		result.Reason = statusText
+		return result
+	}
+
+	// Fallback if no healthcheck is configured
+	if len(c.Expect.Status) == 0 && statusCode > 500 {
+		return result.failed(HealthCheckCause_Status, fmt.Sprintf("Fallbackcheck returns error status %v ", statusCode))
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// Check connects to the address of the target
+func (c *TCPHealthChecker) Check(ctx context.Context, target HealthCheckTarget) HealthCheckResult {
+	result := HealthCheckResult{Type: HealthCheckType_TCP}
+
+	start := time.Now()
+	conn, err := c.Dialer.DialContext(ctx, "tcp", target.Address)
+	result.Latency = time.Since(start)
+	if err != nil {
+		return result.failed(connectionCause(err), err.Error())
+	}
+	_ = conn.Close()
+
+	result.Healthy = true
+	return result
+}
+
+// failed marks the result as unhealthy
+func (r HealthCheckResult) failed(cause, reason string) HealthCheckResult {
+	r.Healthy = false
+	r.Cause = cause
+	r.Reason = reason
+	return r
+}
+
+// connectionCause tells timeouts from other connection errors
+func connectionCause(err error) string {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return HealthCheckCause_Timeout
+	}
+	return HealthCheckCause_Connection
+}
+
+// setDependencyMetrics sets the application_health_dependency metric of the services reported by the healthcheck of the app
+func setDependencyMetrics(status AppDeploymentInfo, services []HealthCheckService) {
+	for _, service := range services {
+		s := float64(0)
+		if !service.Alive {
+			s = 1
+		}
+		healthcheckDependencies.With(prometheus.Labels{"application": status.Name, "dependency": service.Name, "team": status.VistectureApp.Team, "environment": status.Environment}).Set(s)
+	}
+}
+
+// observeCheckDuration adds the duration of a check to the application_health_check_duration_seconds metric, checks not sent are skipped
+func observeCheckDuration(status AppDeploymentInfo, check string, duration time.Duration) {
+	if duration <= 0 {
+		return
+	}
+	labels := prometheus.Labels{"application": status.Name, "team": status.VistectureApp.Team, "environment": status.Environment, "check": check}
+	healthcheckDuration.With(labels).Observe(duration.Seconds())
+}
+
+// recentCheckDurations returns the durations of the recent results, given newest first, in chronological order
+func recentCheckDurations(results []AppDeploymentInfo) []time.Duration {
+	var durations []time.Duration
+	for i := len(results) - 1; i >= 0; i-- {
+		if d := results[i].AppStateInfo.CheckDuration; d > 0 {
+			durations = append(durations, d)
+		}
+	}
+	return durations
+}
@@ -0,0 +1,236 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+type (
+	// HTTPExpectations are the assertions on the response of an HTTP check, configured by the healthCheckExpect* properties
+	HTTPExpectations struct {
+		// Status are the accepted status codes, all if empty
+		Status []StatusRange
+		// Header are the required response headers, the value has to be contained in the header ignoring case, an empty value only requires the header
+		Header http.Header
+		// Body has to match the response body
+		Body *regexp.Regexp
+		// JSONPath has to exist in the response, and all values it finds to equal JSONValue if set
+		JSONPath  *jsonPathExpression
+		JSONValue *string
+	}
+
+	// StatusRange is a range of status codes, From and To included
+	StatusRange struct {
+		From, To int
+	}
+
+	// jsonPathExpression is a parsed kubectl style JSONPath, e.g. "$.checks[0].status" or "{.checks[?(@.name=='db')].status}".
+	// The mutex guards the parsed path, as finding results is not safe for concurrent use.
+	jsonPathExpression struct {
+		expression string
+		mu         sync.Mutex
+		path       *jsonpath.JSONPath
+	}
+)
+
+// newHTTPExpectations reads the expectations of the properties of an app
+func newHTTPExpectations(properties map[string]string) (HTTPExpectations, error) {
+	var e HTTPExpectations
+
+	if status := properties["healthCheckExpectStatus"]; status != "" {
+		ranges, err := parseStatusRanges(status)
+		if err != nil {
+			return e, fmt.Errorf("healthCheckExpectStatus: %w", err)
+		}
+		e.Status = ranges
+	}
+
+	if headers := properties["healthCheckExpectHeaders"]; headers != "" {
+		e.Header = parseHeaderLines(headers)
+	}
+
+	if body := properties["healthCheckExpectBody"]; body != "" {
+		re, err := regexp.Compile(body)
+		if err != nil {
+			return e, fmt.Errorf("healthCheckExpectBody: %w", err)
+		}
+		e.Body = re
+	}
+
+	if path := properties["healthCheckExpectJsonPath"]; path != "" {
+		expression, err := parseJSONPath(path)
+		if err != nil {
+			return e, fmt.Errorf("healthCheckExpectJsonPath: %w", err)
+		}
+		e.JSONPath = expression
+		if value, ok := properties["healthCheckExpectJsonValue"]; ok {
+			e.JSONValue = &value
+		}
+	}
+
+	return e, nil
+}
+
+// parseStatusRanges parses a comma separated list of status codes and ranges, e.g. "200,204,300-399"
+func parseStatusRanges(s string) ([]StatusRange, error) {
+	var ranges []StatusRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		fromCode, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status %q", part)
+		}
+		toCode := fromCode
+		if isRange {
+			if toCode, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || toCode < fromCode {
+				return nil, fmt.Errorf("invalid status range %q", part)
+			}
+		}
+		ranges = append(ranges, StatusRange{From: fromCode, To: toCode})
+	}
+	return ranges, nil
+}
+
+// parseHeaderLines parses headers given one per line as "Name: value"
+func parseHeaderLines(s string) http.Header {
+	header := make(http.Header)
+	for _, line := range strings.Split(s, "\n") {
+		name, value, _ := strings.Cut(line, ":")
+		if name = strings.TrimSpace(name); name != "" {
+			header.Add(name, strings.TrimSpace(value))
+		}
+	}
+	return header
+}
+
+// parseJSONPath parses a JSONPath starting with $ or ., the braces of the kubectl template syntax are optional
+func parseJSONPath(expression string) (*jsonPathExpression, error) {
+	template := expression
+	if !strings.HasPrefix(template, "{") {
+		if !strings.HasPrefix(template, "$") && !strings.HasPrefix(template, ".") {
+			return nil, fmt.Errorf("%q does not start with $", expression)
+		}
+		template = "{" + template + "}"
+	}
+
+	path := jsonpath.New("healthCheckExpectJsonPath")
+	if err := path.Parse(template); err != nil {
+		return nil, err
+	}
+
+	return &jsonPathExpression{expression: expression, path: path}, nil
+}
+
+// find returns the values of the path in the document, an error if it finds nothing
+func (p *jsonPathExpression) find(document any) ([]any, error) {
+	p.mu.Lock()
+	results, err := p.path.FindResults(document)
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var values []any
+	for _, result := range results {
+		for _, value := range result {
+			values = append(values, value.Interface())
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%v not found", p.expression)
+	}
+
+	return values, nil
+}
+
+// check returns why the response does not meet the expectations, nil if it does
+func (e HTTPExpectations) check(r *http.Response, body []byte) error {
+	for name, values := range e.Header {
+		got := r.Header.Values(name)
+		if len(got) == 0 {
+			return fmt.Errorf("header %v missing", name)
+		}
+		for _, value := range values {
+			if value != "" && !headerContains(got, value) {
+				return fmt.Errorf("header %v is %q, expected %q", name, strings.Join(got, ", "), value)
+			}
+		}
+	}
+
+	if e.Body != nil && !e.Body.Match(body) {
+		return fmt.Errorf("body does not match %v", e.Body)
+	}
+
+	if e.JSONPath != nil {
+		var document any
+		if err := json.Unmarshal(body, &document); err != nil {
+			return fmt.Errorf("body is no JSON: %w", err)
+		}
+		values, err := e.JSONPath.find(document)
+		if err != nil {
+			return err
+		}
+		for _, value := range values {
+			if got := jsonValueString(value); e.JSONValue != nil && got != *e.JSONValue {
+				return fmt.Errorf("%v is %q, expected %q", e.JSONPath.expression, got, *e.JSONValue)
+			}
+		}
+	}
+
+	return nil
+}
+
+// statusAccepted is true if the status is in one of the expected ranges
+func (e HTTPExpectations) statusAccepted(status int) bool {
+	for _, r := range e.Status {
+		if status >= r.From && status <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
+// needsBody is true if the body has to be read for the expectations
+func (e HTTPExpectations) needsBody() bool {
+	return e.Body != nil || e.JSONPath != nil
+}
+
+// jsonValueString formats a JSON value to compare it to the expected value, strings without quotes
+func jsonValueString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+func headerContains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), strings.ToLower(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// healthCheckMaxLatency reads the healthCheckMaxLatency property, 0 if not set
+func healthCheckMaxLatency(properties map[string]string) (time.Duration, error) {
+	maxLatency, ok := properties["healthCheckMaxLatency"]
+	if !ok {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(maxLatency)
+	if err != nil {
+		return 0, fmt.Errorf("healthCheckMaxLatency: %w", err)
+	}
+	return d, nil
+}
@@ -0,0 +1,135 @@
+package kube
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := parseStatusRanges("200, 204,300-399")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []StatusRange{{200, 200}, {204, 204}, {300, 399}}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected %v, got %v", expected, ranges)
+	}
+
+	for _, invalid := range []string{"", "ok", "299-200", "200-"} {
+		if _, err := parseStatusRanges(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	document := map[string]any{"checks": []any{
+		map[string]any{"name": "db", "status": "UP"},
+		map[string]any{"name": "cache", "status": "DOWN"},
+	}}
+
+	testCases := map[string][]any{
+		"$.checks[1].status":                {"DOWN"},
+		".checks[0].status":                 {"UP"},
+		"{.checks[?(@.name=='db')].status}": {"UP"},
+		"$.checks[*].name":                  {"db", "cache"},
+	}
+	for expression, expected := range testCases {
+		path, err := parseJSONPath(expression)
+		if err != nil {
+			t.Fatalf("%q: %v", expression, err)
+		}
+		if values, err := path.find(document); err != nil || !reflect.DeepEqual(values, expected) {
+			t.Errorf("%q: expected %v, got %v %v", expression, expected, values, err)
+		}
+	}
+
+	path, _ := parseJSONPath("$.checks[2]")
+	if _, err := path.find(document); err == nil {
+		t.Error("expected an error for a missing index")
+	}
+
+	for _, invalid := range []string{"checks", "$.checks[x]", "$.checks[0"} {
+		if _, err := parseJSONPath(invalid); err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
+
+func TestHTTPHealthChecker_Expectations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"version": "1.2.3", "checks": [{"status": "UP", "count": 3}]}`))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name       string
+		properties map[string]string
+		cause      string
+	}{
+		{"all met", map[string]string{
+			"healthCheckExpectStatus":    "200-202",
+			"healthCheckExpectHeaders":   "Content-Type: application/json",
+			"healthCheckExpectBody":      `"version": "1\.`,
+			"healthCheckExpectJsonPath":  "$.checks[0].count",
+			"healthCheckExpectJsonValue": "3",
+		}, ""},
+		{"status", map[string]string{"healthCheckExpectStatus": "200"}, HealthCheckCause_Status},
+		{"header missing", map[string]string{"healthCheckExpectHeaders": "X-Version"}, HealthCheckCause_Expectation},
+		{"header value", map[string]string{"healthCheckExpectHeaders": "Content-Type: text/html"}, HealthCheckCause_Expectation},
+		{"body", map[string]string{"healthCheckExpectBody": "DOWN"}, HealthCheckCause_Expectation},
+		{"json path missing", map[string]string{"healthCheckExpectJsonPath": "$.checks[1]"}, HealthCheckCause_Expectation},
+		{"json value", map[string]string{"healthCheckExpectJsonPath": "$.checks[0].status", "healthCheckExpectJsonValue": "DOWN"}, HealthCheckCause_Expectation},
+		{"json filter", map[string]string{"healthCheckExpectJsonPath": "$.checks[?(@.status=='UP')].count", "healthCheckExpectJsonValue": "3"}, ""},
+	}
+
+	for _, testCase := range testCases {
+		testCase.properties["healthCheckMethod"] = "post"
+		testCase.properties["healthCheckRequestHeaders"] = "Authorization: Bearer token\nAccept: application/json"
+		checker, err := newHTTPHealthChecker(testCase.properties)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.name, err)
+		}
+		checker.Client = server.Client()
+
+		result := checker.Check(t.Context(), HealthCheckTarget{Address: strings.TrimPrefix(server.URL, "http://"), Scheme: "http"})
+		if result.Healthy != (testCase.cause == "") || result.Cause != testCase.cause {
+			t.Errorf("%s: unexpected result %+v", testCase.name, result)
+		}
+	}
+}
+
+func TestNewHTTPHealthChecker_Invalid(t *testing.T) {
+	for _, properties := range []map[string]string{
+		{"healthCheckMethod": "DELETE"},
+		{"healthCheckFormat": "xml"},
+		{"healthCheckExpectBody": "("},
+		{"healthCheckExpectJsonPath": "status"},
+	} {
+		if _, err := newHTTPHealthChecker(properties); err == nil {
+			t.Errorf("%v: expected an error", properties)
+		}
+	}
+}
+
+func TestHealthCheckMaxLatency(t *testing.T) {
+	if d, err := healthCheckMaxLatency(map[string]string{"healthCheckMaxLatency": "500ms"}); err != nil || d != 500*time.Millisecond {
+		t.Errorf("expected 500ms, got %v %v", d, err)
+	}
+	if d, err := healthCheckMaxLatency(map[string]string{}); err != nil || d != 0 {
+		t.Errorf("expected no limit, got %v %v", d, err)
+	}
+	if _, err := healthCheckMaxLatency(map[string]string{"healthCheckMaxLatency": "fast"}); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,219 @@
+package kube
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"slices"
+	"strings"
+)
+
+type (
+	// healthCheckBody contains the fields of all supported formats, to detect the format of a response
+	healthCheckBody struct {
+		Services   *[]HealthCheckService        `json:"services"`
+		Status     string                       `json:"status"`
+		Components map[string]actuatorComponent `json:"components"`
+		Checks     json.RawMessage              `json:"checks"`
+		Details    map[string]json.RawMessage   `json:"details"`
+	}
+
+	// actuatorComponent is a component of a Spring Boot Actuator health response, composite components contain further components
+	actuatorComponent struct {
+		Status     string                       `json:"status"`
+		Details    map[string]json.RawMessage   `json:"details"`
+		Components map[string]actuatorComponent `json:"components"`
+	}
+
+	// microProfileCheck is a check of a MicroProfile Health response
+	microProfileCheck struct {
+		Name   string                     `json:"name"`
+		Status string                     `json:"status"`
+		Data   map[string]json.RawMessage `json:"data"`
+	}
+
+	// healthJSONCheck is a check of an application/health+json response (draft-inadarei-api-health-check)
+	healthJSONCheck struct {
+		ComponentID   string          `json:"componentId"`
+		Status        string          `json:"status"`
+		Output        string          `json:"output"`
+		ObservedValue json.RawMessage `json:"observedValue"`
+		ObservedUnit  string          `json:"observedUnit"`
+	}
+)
+
+const (
+	// values of the healthCheckFormat property
+	HealthCheckFormat_Auto         = "auto"
+	HealthCheckFormat_Services     = "services"
+	HealthCheckFormat_Actuator     = "actuator"
+	HealthCheckFormat_MicroProfile = "microprofile"
+	HealthCheckFormat_HealthJSON   = "health+json"
+
+	healthJSONContentType = "application/health+json"
+)
+
+var healthCheckFormats = []string{HealthCheckFormat_Auto, HealthCheckFormat_Services, HealthCheckFormat_Actuator, HealthCheckFormat_MicroProfile, HealthCheckFormat_HealthJSON}
+
+// parseHealthCheckResponse maps the response of the format to the services of the app and returns whether the app itself reports to be up.
+// The auto format detects the format by the content type and the fields of the response, and falls back to the services format.
+func parseHealthCheckResponse(format string, contentType string, body []byte) (bool, []HealthCheckService, error) {
+	var parsed healthCheckBody
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return false, nil, err
+	}
+
+	if format == "" || format == HealthCheckFormat_Auto {
+		format = detectHealthCheckFormat(contentType, parsed)
+	}
+
+	switch format {
+	case HealthCheckFormat_Services:
+		if parsed.Services == nil {
+			return true, []HealthCheckService{}, nil
+		}
+		return true, *parsed.Services, nil
+	case HealthCheckFormat_Actuator:
+		if parsed.Status == "" {
+			return false, nil, errors.New("status missing")
+		}
+		services := actuatorServices("", parsed.Components)
+		if len(parsed.Components) == 0 && len(parsed.Details) > 0 {
+			// responses of Spring Boot 2.0 and 2.1 contain the components as details
+			services = actuatorServices("", actuatorDetailsComponents(parsed.Details))
+		}
+		return isUp(parsed.Status), services, nil
+	case HealthCheckFormat_MicroProfile:
+		var checks []microProfileCheck
+		if len(parsed.Checks) > 0 {
+			if err := json.Unmarshal(parsed.Checks, &checks); err != nil {
+				return false, nil, fmt.Errorf("checks: %w", err)
+			}
+		}
+		if parsed.Status == "" {
+			return false, nil, errors.New("status missing")
+		}
+		services := make([]HealthCheckService, 0, len(checks))
+		for _, check := range checks {
+			services = append(services, HealthCheckService{Name: check.Name, Alive: isUp(check.Status), Details: compactDetails(check.Data)})
+		}
+		return isUp(parsed.Status), services, nil
+	case HealthCheckFormat_HealthJSON:
+		checks := make(map[string][]healthJSONCheck)
+		if len(parsed.Checks) > 0 {
+			if err := json.Unmarshal(parsed.Checks, &checks); err != nil {
+				return false, nil, fmt.Errorf("checks: %w", err)
+			}
+		}
+		if parsed.Status == "" {
+			return false, nil, errors.New("status missing")
+		}
+		return isUp(parsed.Status), healthJSONServices(checks), nil
+	}
+
+	return false, nil, fmt.Errorf("unsupported healthCheckFormat %v", format)
+}
+
+// detectHealthCheckFormat guesses the format of the response
+func detectHealthCheckFormat(contentType string, parsed healthCheckBody) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == healthJSONContentType {
+		return HealthCheckFormat_HealthJSON
+	}
+
+	switch {
+	case parsed.Services != nil:
+		return HealthCheckFormat_Services
+	case strings.HasPrefix(string(parsed.Checks), "["):
+		return HealthCheckFormat_MicroProfile
+	case strings.HasPrefix(string(parsed.Checks), "{"):
+		return HealthCheckFormat_HealthJSON
+	case parsed.Components != nil:
+		return HealthCheckFormat_Actuator
+	case parsed.Status != "":
+		// the health+json draft uses lower case states, actuator upper case
+		if strings.ToUpper(parsed.Status) == parsed.Status {
+			return HealthCheckFormat_Actuator
+		}
+		return HealthCheckFormat_HealthJSON
+	}
+
+	return HealthCheckFormat_Services
+}
+
+// isUp is true for the states of all formats that are not failing: UP of actuator and MicroProfile, pass, ok, up and warn of health+json.
+// UNKNOWN is up as well, as actuator responds with status 200 for it.
+func isUp(status string) bool {
+	switch strings.ToLower(status) {
+	case "up", "pass", "ok", "warn", "unknown":
+		return true
+	}
+	return false
+}
+
+// actuatorServices flattens the components, the names of nested components are joined with a slash
+func actuatorServices(prefix string, components map[string]actuatorComponent) []HealthCheckService {
+	services := make([]HealthCheckService, 0, len(components))
+	for _, name := range slices.Sorted(maps.Keys(components)) {
+		component := components[name]
+		if len(component.Components) > 0 {
+			services = append(services, actuatorServices(prefix+name+"/", component.Components)...)
+			continue
+		}
+		services = append(services, HealthCheckService{Name: prefix + name, Alive: isUp(component.Status), Details: compactDetails(component.Details)})
+	}
+	return services
+}
+
+// actuatorDetailsComponents reads the components of old actuator responses, the details that have a status
+func actuatorDetailsComponents(details map[string]json.RawMessage) map[string]actuatorComponent {
+	components := make(map[string]actuatorComponent)
+	for name, raw := range details {
+		var component actuatorComponent
+		if json.Unmarshal(raw, &component) == nil && component.Status != "" {
+			components[name] = component
+		}
+	}
+	return components
+}
+
+// healthJSONServices maps the checks, named "component:measurement", to one service per check
+func healthJSONServices(checks map[string][]healthJSONCheck) []HealthCheckService {
+	var services []HealthCheckService
+	for _, name := range slices.Sorted(maps.Keys(checks)) {
+		for _, check := range checks[name] {
+			serviceName := name
+			if check.ComponentID != "" && len(checks[name]) > 1 {
+				serviceName += " " + check.ComponentID
+			}
+
+			details := check.Output
+			if details == "" && len(check.ObservedValue) > 0 {
+				details = strings.TrimSpace(string(check.ObservedValue) + " " + check.ObservedUnit)
+			}
+			services = append(services, HealthCheckService{Name: serviceName, Alive: isUp(check.Status), Details: details})
+		}
+	}
+	if services == nil {
+		services = []HealthCheckService{}
+	}
+	return services
+}
+
+// compactDetails formats the details of a dependency as JSON
+func compactDetails(details map[string]json.RawMessage) string {
+	if len(details) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(details)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// validHealthCheckFormat is true for the supported values of the healthCheckFormat property
+func validHealthCheckFormat(format string) bool {
+	return format == "" || slices.Contains(healthCheckFormats, format)
+}
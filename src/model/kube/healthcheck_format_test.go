@@ -0,0 +1,110 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseHealthCheckResponse(t *testing.T) {
+	testCases := []struct {
+		name        string
+		format      string
+		contentType string
+		body        string
+		up          bool
+		services    []HealthCheckService
+	}{
+		{
+			name:     "services",
+			body:     `{"services": [{"name": "db", "alive": false, "details": "timeout"}]}`,
+			up:       true,
+			services: []HealthCheckService{{Name: "db", Alive: false, Details: "timeout"}},
+		},
+		{
+			name:     "empty object is the services format",
+			body:     `{}`,
+			up:       true,
+			services: []HealthCheckService{},
+		},
+		{
+			name: "actuator",
+			body: `{"status": "DOWN", "components": {"db": {"status": "DOWN", "details": {"error": "refused"}},
+				"redis": {"status": "UP"}, "cache": {"status": "UP", "components": {"local": {"status": "UP"}}}}}`,
+			up: false,
+			services: []HealthCheckService{
+				{Name: "cache/local", Alive: true},
+				{Name: "db", Alive: false, Details: `{"error":"refused"}`},
+				{Name: "redis", Alive: true},
+			},
+		},
+		{
+			name:     "actuator without components",
+			body:     `{"status": "UP"}`,
+			up:       true,
+			services: []HealthCheckService{},
+		},
+		{
+			name:     "actuator 2.0 details",
+			format:   HealthCheckFormat_Actuator,
+			body:     `{"status": "UP", "details": {"diskSpace": {"status": "UP", "details": {"free": 1}}}}`,
+			up:       true,
+			services: []HealthCheckService{{Name: "diskSpace", Alive: true, Details: `{"free":1}`}},
+		},
+		{
+			name:     "microprofile",
+			body:     `{"status": "UP", "checks": [{"name": "kafka", "status": "DOWN", "data": {"broker": "b1"}}, {"name": "db", "status": "UP"}]}`,
+			up:       true,
+			services: []HealthCheckService{{Name: "kafka", Alive: false, Details: `{"broker":"b1"}`}, {Name: "db", Alive: true}},
+		},
+		{
+			name:        "health+json by content type",
+			contentType: "application/health+json; charset=utf-8",
+			body: `{"status": "warn", "checks": {"cassandra:responseTime": [{"componentId": "c1", "status": "pass", "observedValue": 250, "observedUnit": "ms"},
+				{"componentId": "c2", "status": "fail", "output": "timeout"}], "uptime": [{"status": "pass"}]}}`,
+			up: true,
+			services: []HealthCheckService{
+				{Name: "cassandra:responseTime c1", Alive: true, Details: "250 ms"},
+				{Name: "cassandra:responseTime c2", Alive: false, Details: "timeout"},
+				{Name: "uptime", Alive: true},
+			},
+		},
+		{
+			name:     "health+json by status",
+			body:     `{"status": "fail"}`,
+			up:       false,
+			services: []HealthCheckService{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		up, services, err := parseHealthCheckResponse(testCase.format, testCase.contentType, []byte(testCase.body))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", testCase.name, err)
+			continue
+		}
+		if up != testCase.up {
+			t.Errorf("%s: expected up %v, got %v", testCase.name, testCase.up, up)
+		}
+		if !reflect.DeepEqual(services, testCase.services) {
+			t.Errorf("%s: expected services %+v, got %+v", testCase.name, testCase.services, services)
+		}
+	}
+}
+
+func TestParseHealthCheckResponse_Errors(t *testing.T) {
+	testCases := []struct {
+		format string
+		body   string
+	}{
+		{"", `not json`},
+		{HealthCheckFormat_Actuator, `{"components": {}}`},
+		{HealthCheckFormat_MicroProfile, `{"status": "UP", "checks": {"db": []}}`},
+		{HealthCheckFormat_HealthJSON, `{"checks": {}}`},
+	}
+
+	for _, testCase := range testCases {
+		if _, _, err := parseHealthCheckResponse(testCase.format, "application/json", []byte(testCase.body)); err == nil {
+			t.Errorf("%q %s: expected an error", testCase.format, testCase.body)
+		}
+	}
+}
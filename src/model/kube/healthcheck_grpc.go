@@ -0,0 +1,62 @@
+package kube
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCHealthChecker calls the standard grpc.health.v1.Health/Check of the target without TLS, as gRPC services inside the cluster usually do
+type GRPCHealthChecker struct {
+	// Service is the name of the checked service, empty for the overall health of the server
+	Service string
+	// DialOptions are added to the options of the connection
+	DialOptions []grpc.DialOption
+}
+
+var _ HealthChecker = new(GRPCHealthChecker)
+
+// Check sends a HealthCheckRequest and is healthy if the status is SERVING, the latency includes establishing the connection
+func (c *GRPCHealthChecker) Check(ctx context.Context, target HealthCheckTarget) HealthCheckResult {
+	result := HealthCheckResult{Type: HealthCheckType_GRPC}
+
+	options := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent(healthCheckUserAgent),
+	}, c.DialOptions...)
+	conn, err := grpc.NewClient(target.Address, options...)
+	if err != nil {
+		return result.failed(HealthCheckCause_Connection, err.Error())
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: c.Service})
+	result.Latency = time.Since(start)
+	if err != nil {
+		return result.failed(grpcCause(err), "gRPC health check failed: "+status.Convert(err).Message())
+	}
+	if response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return result.failed(HealthCheckCause_Status, "gRPC health check status "+response.GetStatus().String())
+	}
+
+	result.Healthy = true
+	return result
+}
+
+// grpcCause categorizes the status code of a failed call
+func grpcCause(err error) string {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return HealthCheckCause_Connection
+	case codes.DeadlineExceeded:
+		return HealthCheckCause_Timeout
+	default:
+		return HealthCheckCause_Status
+	}
+}
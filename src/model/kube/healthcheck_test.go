@@ -0,0 +1,248 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// checkHTTP runs the HTTP checker against the test server
+func checkHTTP(server *httptest.Server, path string) HealthCheckResult {
+	checker := &HTTPHealthChecker{Client: server.Client()}
+	return checker.Check(context.Background(), HealthCheckTarget{Address: strings.TrimPrefix(server.URL, "http://"), Scheme: "http", Path: path})
+}
+
+func TestCheckHealth_AllHealthy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{\"services\": []}"))
+
+	}))
+	defer server.Close()
+
+	result := checkHTTP(server, "/")
+	if !result.Healthy {
+		t.Errorf("healthStatusOfService should be true %v", result.Reason)
+	}
+	if result.Type != HealthCheckType_HealthCheck {
+		t.Errorf("expected healthcheck type, got %q", result.Type)
+	}
+}
+
+func TestCheckHealth_UnhealthyService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{\"services\": [{\"name\": \"dummy\", \"alive\": false, \"details\": \"dummy\"}]}"))
+	}))
+	defer server.Close()
+
+	result := checkHTTP(server, "/nonexistingpath")
+	if result.Healthy {
+		t.Errorf("healthStatusOfService should be false")
+	}
+	if result.Cause != HealthCheckCause_Dependency {
+		t.Errorf("expected cause dependency, got %q", result.Cause)
+	}
+	if len(result.Services) != 1 || result.Services[0].Name != "dummy" || result.Services[0].Alive {
+		t.Errorf("expected the dead dummy service, got %+v", result.Services)
+	}
+}
+
+func TestCheckHealth_UserAgentIsSet(t *testing.T) {
+	expectedUA := "VistectureDashboard"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua := r.Header.Get("User-Agent")
+		if ua != expectedUA {
+			w.WriteHeader(500)
+			t.Errorf("expected user-agent to be '%s' but was '%s'", expectedUA, ua)
+		} else {
+			w.WriteHeader(200)
+		}
+	}))
+	defer server.Close()
+
+	result := checkHTTP(server, "/")
+	if result.Healthy {
+		t.Errorf("user-agent assertion failed")
+	}
+	if result.Cause != HealthCheckCause_Format {
+		t.Errorf("expected cause format for the empty body, got %q", result.Cause)
+	}
+}
+
+func TestHTTPHealthChecker_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	checker := &HTTPHealthChecker{Client: server.Client()}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result := checker.Check(ctx, HealthCheckTarget{Address: strings.TrimPrefix(server.URL, "http://"), Scheme: "http"})
+	if result.Healthy || result.Cause != HealthCheckCause_Timeout {
+		t.Errorf("expected a timeout, got %+v", result)
+	}
+	if result.Latency < 50*time.Millisecond {
+		t.Errorf("expected the latency of the timeout, got %v", result.Latency)
+	}
+}
+
+func TestTCPHealthChecker(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+
+	result := new(TCPHealthChecker).Check(context.Background(), HealthCheckTarget{Address: address})
+	if !result.Healthy || result.Type != HealthCheckType_TCP {
+		t.Errorf("expected healthy tcp check, got %+v", result)
+	}
+
+	_ = listener.Close()
+	result = new(TCPHealthChecker).Check(context.Background(), HealthCheckTarget{Address: address})
+	if result.Healthy || result.Cause != HealthCheckCause_Connection {
+		t.Errorf("expected connection failure, got %+v", result)
+	}
+}
+
+// grpcHealthServer serves the standard health service with the statuses of the services
+func grpcHealthServer(t *testing.T, statuses map[string]healthpb.HealthCheckResponse_ServingStatus) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthServer := health.NewServer()
+	for service, status := range statuses {
+		healthServer.SetServingStatus(service, status)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String()
+}
+
+func TestGRPCHealthChecker(t *testing.T) {
+	target := HealthCheckTarget{Address: grpcHealthServer(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":       healthpb.HealthCheckResponse_SERVING,
+		"search": healthpb.HealthCheckResponse_NOT_SERVING,
+	})}
+
+	testCases := []struct {
+		service string
+		healthy bool
+		cause   string
+		reason  string
+	}{
+		{"", true, "", ""},
+		{"search", false, HealthCheckCause_Status, "NOT_SERVING"},
+		{"unknown", false, HealthCheckCause_Status, "unknown service"},
+	}
+
+	for _, testCase := range testCases {
+		checker := &GRPCHealthChecker{Service: testCase.service}
+		result := checker.Check(context.Background(), target)
+		if result.Healthy != testCase.healthy || result.Cause != testCase.cause || !strings.Contains(result.Reason, testCase.reason) {
+			t.Errorf("service %q: unexpected result %+v", testCase.service, result)
+		}
+		if result.Type != HealthCheckType_GRPC || result.Latency <= 0 {
+			t.Errorf("service %q: expected grpc type and latency, got %+v", testCase.service, result)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := listener.Addr().String()
+	_ = listener.Close()
+	result := new(GRPCHealthChecker).Check(context.Background(), HealthCheckTarget{Address: closed})
+	if result.Healthy || result.Cause != HealthCheckCause_Connection {
+		t.Errorf("expected connection failure, got %+v", result)
+	}
+}
+
+func TestNewHealthChecker(t *testing.T) {
+	testCases := map[string]HealthChecker{
+		"":     &HTTPHealthChecker{},
+		"http": &HTTPHealthChecker{},
+		"tcp":  &TCPHealthChecker{},
+		"grpc": &GRPCHealthChecker{},
+		// alias of tcp
+		"exec-free-tcp": &TCPHealthChecker{},
+	}
+
+	for checkType, expected := range testCases {
+		checker, err := newHealthChecker(&vistectureCore.Application{Properties: map[string]string{"healthCheckType": checkType}})
+		if err != nil {
+			t.Fatalf("%q: %v", checkType, err)
+		}
+		if got, want := fmt.Sprintf("%T", checker), fmt.Sprintf("%T", expected); got != want {
+			t.Errorf("%q: expected %v, got %v", checkType, want, got)
+		}
+	}
+
+	if _, err := newHealthChecker(&vistectureCore.Application{Properties: map[string]string{"healthCheckType": "exec"}}); err == nil {
+		t.Error("expected an error for an unsupported type")
+	}
+}
+
+func TestHTTPHealthChecker_ActuatorDown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"status": "DOWN"}`))
+	}))
+	defer server.Close()
+
+	result := checkHTTP(server, "/actuator/health")
+	if result.Healthy || result.Cause != HealthCheckCause_Status {
+		t.Errorf("expected unhealthy with cause status, got %+v", result)
+	}
+}
+
+func TestRecentCheckDurations(t *testing.T) {
+	// results are given newest first, jobs are not checked
+	results := []AppDeploymentInfo{
+		{AppStateInfo: AppStateInfo{CheckDuration: 30 * time.Millisecond}},
+		{AppStateInfo: AppStateInfo{}},
+		{AppStateInfo: AppStateInfo{CheckDuration: 10 * time.Millisecond}},
+	}
+
+	durations := recentCheckDurations(results)
+	if len(durations) != 2 || durations[0] != 10*time.Millisecond || durations[1] != 30*time.Millisecond {
+		t.Errorf("expected the checked durations oldest first, got %v", durations)
+	}
+}
+
+func TestStatusFetcher_HealthChecksBuiltOnce(t *testing.T) {
+	definedApps := []*vistectureCore.Application{
+		{Name: "api", Properties: map[string]string{"healthCheckExpectJsonPath": "$.status", "k8sHealthCheckThroughIngress": "true"}},
+		{Name: "db", Properties: map[string]string{"healthCheckType": "tcp", "healthCheckMaxLatency": "fast"}},
+	}
+	stm := NewStatusFetcher("prod", definedApps, NewDemoService(0))
+	resources := &kubernetesResources{healthChecks: stm.healthChecks}
+
+	api := resources.healthCheck(definedApps[0])
+	if api != resources.healthCheck(definedApps[0]) || api.err != nil || api.publicChecker == nil {
+		t.Errorf("expected the cached checkers of api, got %+v", api)
+	}
+	if api.publicChecker.Expect.JSONPath != api.checker.(*HTTPHealthChecker).Expect.JSONPath {
+		t.Error("expected the JSONPath to be parsed once")
+	}
+	if db := resources.healthCheck(definedApps[1]); db.err == nil || !strings.Contains(db.err.Error(), "healthCheckMaxLatency") {
+		t.Errorf("expected the invalid max latency of db, got %+v", db)
+	}
+}
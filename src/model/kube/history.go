@@ -0,0 +1,86 @@
+package kube
+
+import (
+	"log"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+)
+
+// restoreHistory loads the last recorded state of each app, to only record changes after a restart
+func (stm *StatusFetcher) restoreHistory() {
+	if stm.History == nil {
+		return
+	}
+
+	stm.mu.Lock()
+	stm.recorded = stm.History.Latest(stm.environment)
+	stm.mu.Unlock()
+}
+
+// recordHistory returns a record for every app whose state changed since its last record, the caller has to hold the write lock.
+// The records have to be stored with storeHistory after releasing the lock.
+func (stm *StatusFetcher) recordHistory(results map[string]AppDeploymentInfo, now time.Time) []history.Record {
+	if stm.History == nil {
+		return nil
+	}
+
+	var records []history.Record
+	for key, status := range results {
+		record := toHistoryRecord(key, status, now)
+		if last, ok := stm.recorded[key]; ok && last.State == record.State && last.OwnState == record.OwnState {
+			continue
+		}
+		records = append(records, record)
+		stm.recorded[key] = record
+	}
+
+	return records
+}
+
+// storeHistory writes the changed states and the check results to the History, the caller must not hold the lock
+func (stm *StatusFetcher) storeHistory(records []history.Record, checks ...history.CheckResult) {
+	if stm.History == nil {
+		return
+	}
+
+	if len(records) > 0 {
+		if err := stm.History.Append(records...); err != nil {
+			log.Printf("StatusFetcher: storing the history failed: %v", err)
+		}
+	}
+	if len(checks) > 0 {
+		if err := stm.History.AppendChecks(checks...); err != nil {
+			log.Printf("StatusFetcher: storing the check results failed: %v", err)
+		}
+	}
+}
+
+// toHistoryRecord converts the result of the app stored with key (the vistecture name)
+func toHistoryRecord(key string, status AppDeploymentInfo, now time.Time) history.Record {
+	record := history.Record{
+		Time:        now,
+		Environment: status.Environment,
+		Application: key,
+		Team:        status.VistectureApp.Team,
+		State:       StateName(status.AppStateInfo.State),
+		Reason:      status.AppStateInfo.StateReason,
+	}
+	if status.AppStateInfo.State == State_impacted {
+		record.OwnState = StateName(status.AppStateInfo.OwnState)
+	}
+
+	return record
+}
+
+// toCheckResult converts the result of the app's own check
+func toCheckResult(status AppDeploymentInfo, now time.Time) history.CheckResult {
+	return history.CheckResult{
+		Time:        now,
+		Environment: status.Environment,
+		Application: status.VistectureApp.Name,
+		State:       StateName(status.AppStateInfo.State),
+		Reason:      status.AppStateInfo.StateReason,
+		DurationMs:  status.AppStateInfo.CheckDuration.Milliseconds(),
+	}
+}
@@ -0,0 +1,105 @@
+package kube
+
+import (
+	"testing"
+	"time"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
+)
+
+type memoryStore struct {
+	records []history.Record
+	checks  []history.CheckResult
+}
+
+func (s *memoryStore) Append(records ...history.Record) error {
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *memoryStore) AppendChecks(checks ...history.CheckResult) error {
+	s.checks = append(s.checks, checks...)
+	return nil
+}
+
+func (s *memoryStore) Query(history.Query) []history.Record {
+	return s.records
+}
+
+func (s *memoryStore) QueryChecks(history.Query) []history.CheckResult {
+	return s.checks
+}
+
+func (s *memoryStore) Latest(environment string) map[string]history.Record {
+	latest := make(map[string]history.Record)
+	for _, record := range s.records {
+		if record.Environment == environment {
+			latest[record.Application] = record
+		}
+	}
+	return latest
+}
+
+func TestStatusFetcher_RecordHistory(t *testing.T) {
+	store := &memoryStore{records: []history.Record{
+		{Environment: "prod", Application: "akeneo", State: "healthy"},
+	}}
+	stm := NewStatusFetcher("prod", nil, NewDemoService(0))
+	stm.History = store
+	stm.restoreHistory()
+
+	result := func(state, ownState uint, reason string) AppDeploymentInfo {
+		return AppDeploymentInfo{
+			Environment:   "prod",
+			VistectureApp: vistectureCore.Application{Name: "flamingo", Team: "Team 1"},
+			AppStateInfo:  AppStateInfo{State: state, OwnState: ownState, StateReason: reason},
+		}
+	}
+
+	now := time.Now()
+	record := func(results map[string]AppDeploymentInfo, now time.Time) {
+		stm.storeHistory(stm.recordHistory(results, now))
+	}
+	record(map[string]AppDeploymentInfo{
+		"akeneo":   {Environment: "prod", AppStateInfo: AppStateInfo{State: State_healthy}},
+		"flamingo": result(State_healthy, 0, ""),
+	}, now)
+	record(map[string]AppDeploymentInfo{"flamingo": result(State_healthy, 0, "")}, now.Add(time.Minute))
+	record(map[string]AppDeploymentInfo{"flamingo": result(State_impacted, State_healthy, "Impacted by akeneo (failed)")}, now.Add(2*time.Minute))
+	record(map[string]AppDeploymentInfo{"flamingo": result(State_impacted, State_unhealthy, "Impacted by akeneo (failed)")}, now.Add(3*time.Minute))
+
+	records := store.records[1:]
+	if len(records) != 3 {
+		t.Fatalf("expected only the changes of flamingo to be recorded, got %+v", records)
+	}
+	if records[0].State != "healthy" || records[0].Team != "Team 1" || !records[0].Time.Equal(now) {
+		t.Errorf("unexpected first record %+v", records[0])
+	}
+	if records[1].State != "impacted" || records[1].OwnState != "healthy" || records[2].OwnState != "unhealthy" {
+		t.Errorf("expected the changes of the own state to be recorded, got %+v", records[1:])
+	}
+}
+
+func TestStatusFetcher_CheckAppStoresCheckResult(t *testing.T) {
+	store := &memoryStore{}
+	app := &vistectureCore.Application{Name: "flamingo", Properties: map[string]string{"deployment": "kubernetes"}}
+	stm := NewStatusFetcher("prod", []*vistectureCore.Application{app}, NewDemoService(0))
+	stm.History = store
+	rollUpDelay = time.Hour
+	defer func() { rollUpDelay = time.Second }()
+
+	stm.checkApp(nil, app, &kubernetesResources{})
+	if len(store.checks) != 0 || !stm.rollUpScheduled {
+		t.Fatalf("expected the check result to wait for the roll-up, got %+v", store.checks)
+	}
+	stm.rollUp()
+
+	if len(store.checks) != 1 || store.checks[0].Application != "flamingo" || store.checks[0].State != "unknown" || store.checks[0].Reason != "No deployment found" {
+		t.Errorf("expected the check result to be stored, got %+v", store.checks)
+	}
+	if len(store.records) != 1 || store.records[0].State != "unknown" {
+		t.Errorf("expected the first state to be recorded, got %+v", store.records)
+	}
+}
@@ -0,0 +1,138 @@
+package kube
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+)
+
+type (
+	// dependencyGraph maps the name of an app to the names of the apps it depends on
+	dependencyGraph map[string][]string
+)
+
+// newDependencyGraph builds the graph from the vistecture dependencies, references to unknown apps are skipped
+func newDependencyGraph(apps []*vistectureCore.Application) dependencyGraph {
+	known := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		known[app.Name] = true
+	}
+
+	graph := make(dependencyGraph, len(apps))
+	for _, app := range apps {
+		for _, dependency := range app.Dependencies {
+			name := dependencyAppName(dependency.Reference)
+			if !known[name] || name == app.Name || slices.Contains(graph[app.Name], name) {
+				continue
+			}
+			graph[app.Name] = append(graph[app.Name], name)
+		}
+	}
+
+	return graph
+}
+
+// dependencyAppName returns the app of a vistecture dependency reference like "akeneo.api" or "akeneo"
+func dependencyAppName(reference string) string {
+	name, _, _ := strings.Cut(reference, ".")
+	return name
+}
+
+// IsBroken is true for states that impact the apps depending on it
+func IsBroken(state uint) bool {
+	return state == State_failed || state == State_unhealthy
+}
+
+// brokenDependencies returns all direct and indirect dependencies of the app that are failed or unhealthy
+func (g dependencyGraph) brokenDependencies(name string, results map[string]AppDeploymentInfo) []string {
+	var broken []string
+	visited := map[string]bool{name: true}
+	queue := slices.Clone(g[name])
+	for len(queue) > 0 {
+		dependency := queue[0]
+		queue = queue[1:]
+		if visited[dependency] {
+			continue
+		}
+		visited[dependency] = true
+
+		if result, ok := results[dependency]; ok && IsBroken(result.AppStateInfo.State) {
+			broken = append(broken, dependency)
+		}
+		queue = append(queue, g[dependency]...)
+	}
+
+	sort.Strings(broken)
+	return broken
+}
+
+// applyImpacts marks apps with broken dependencies as impacted by the root causes, see applyImpact, and lists the impacted apps at the root causes.
+// The results contain the own state of each app, they are not modified.
+func (g dependencyGraph) applyImpacts(results map[string]AppDeploymentInfo) map[string]AppDeploymentInfo {
+	impacted := make(map[string]AppDeploymentInfo, len(results))
+	impacts := make(map[string][]string)
+
+	for name := range results {
+		result := g.applyImpact(name, results)
+		for _, rootCause := range result.AppStateInfo.ImpactedBy {
+			impacts[rootCause] = append(impacts[rootCause], name)
+		}
+		impacted[name] = result
+	}
+
+	for rootCause, apps := range impacts {
+		result := impacted[rootCause]
+		sort.Strings(apps)
+		result.AppStateInfo.Impacts = apps
+		impacted[rootCause] = result
+	}
+
+	return impacted
+}
+
+// applyImpact returns the result of the app marked as impacted by the root causes, which are the broken dependencies that have no broken dependencies themselves.
+// A failed or unhealthy app keeps its state, it only gets the root causes added. The Impacts of the app are not set.
+func (g dependencyGraph) applyImpact(name string, results map[string]AppDeploymentInfo) AppDeploymentInfo {
+	result := results[name]
+	if result.AppStateInfo.State == State_ignored || result.AppStateInfo.State == State_unknown {
+		return result
+	}
+
+	broken := g.brokenDependencies(name, results)
+	if len(broken) == 0 {
+		return result
+	}
+
+	var rootCauses []string
+	for _, dependency := range broken {
+		if len(g.brokenDependencies(dependency, results)) == 0 {
+			rootCauses = append(rootCauses, dependency)
+		}
+	}
+	if len(rootCauses) == 0 {
+		// the broken dependencies depend on each other, all of them are suspects
+		rootCauses = broken
+	}
+
+	var causes []string
+	for _, rootCause := range rootCauses {
+		causes = append(causes, fmt.Sprintf("%s (%s)", rootCause, StateName(results[rootCause].AppStateInfo.State)))
+	}
+
+	// an app that is broken itself keeps its state, it might have another root cause
+	if !IsBroken(result.AppStateInfo.State) {
+		result.AppStateInfo.OwnState = result.AppStateInfo.State
+		result.AppStateInfo.State = State_impacted
+	}
+	result.AppStateInfo.ImpactedBy = rootCauses
+	reason := "Impacted by " + strings.Join(causes, ", ")
+	if result.AppStateInfo.StateReason != "" {
+		reason += "\n" + result.AppStateInfo.StateReason
+	}
+	result.AppStateInfo.StateReason = reason
+
+	return result
+}
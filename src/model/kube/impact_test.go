@@ -0,0 +1,78 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+)
+
+func TestDependencyGraph_ApplyImpacts(t *testing.T) {
+	app := func(name string, dependencies ...string) *vistectureCore.Application {
+		a := &vistectureCore.Application{Name: name}
+		for _, dependency := range dependencies {
+			a.Dependencies = append(a.Dependencies, vistectureCore.DependencyReference{Reference: dependency})
+		}
+		return a
+	}
+	// shop -> flamingo -> akeneo.api -> db, flamingo -> keycloak
+	graph := newDependencyGraph([]*vistectureCore.Application{
+		app("shop", "flamingo"),
+		app("flamingo", "akeneo.api", "keycloak", "external.service"),
+		app("akeneo", "db"),
+		app("keycloak"),
+		app("db"),
+	})
+
+	result := func(name string, state uint, reason string) AppDeploymentInfo {
+		return AppDeploymentInfo{Name: name, VistectureApp: vistectureCore.Application{Name: name}, AppStateInfo: AppStateInfo{State: state, StateReason: reason}}
+	}
+	results := map[string]AppDeploymentInfo{
+		"shop":     result("shop", State_healthy, ""),
+		"flamingo": result("flamingo", State_unhealthy, "Service Unhealthy"),
+		"akeneo":   result("akeneo", State_failed, "No pod available"),
+		"keycloak": result("keycloak", State_healthy, ""),
+		"db":       result("db", State_failed, "No pod available"),
+	}
+
+	impacted := graph.applyImpacts(results)
+
+	shop := impacted["shop"].AppStateInfo
+	if shop.State != State_impacted || shop.OwnState != State_healthy || !reflect.DeepEqual(shop.ImpactedBy, []string{"db"}) {
+		t.Errorf("expected shop to be impacted by db, got %+v", shop)
+	}
+
+	flamingo := impacted["flamingo"].AppStateInfo
+	if flamingo.State != State_unhealthy || !reflect.DeepEqual(flamingo.ImpactedBy, []string{"db"}) || flamingo.StateReason != "Impacted by db (failed)\nService Unhealthy" {
+		t.Errorf("expected flamingo to stay unhealthy and be impacted by db, got %+v", flamingo)
+	}
+
+	db := impacted["db"].AppStateInfo
+	if db.State != State_failed || !reflect.DeepEqual(db.Impacts, []string{"akeneo", "flamingo", "shop"}) {
+		t.Errorf("expected db to be the root cause, got %+v", db)
+	}
+
+	if keycloak := impacted["keycloak"].AppStateInfo; keycloak.State != State_healthy || keycloak.Impacts != nil {
+		t.Errorf("expected keycloak to be untouched, got %+v", keycloak)
+	}
+
+	if results["shop"].AppStateInfo.State != State_healthy {
+		t.Error("expected the own results not to be modified")
+	}
+}
+
+func TestDependencyGraph_Cycle(t *testing.T) {
+	graph := newDependencyGraph([]*vistectureCore.Application{
+		{Name: "a", Dependencies: []vistectureCore.DependencyReference{{Reference: "b"}}},
+		{Name: "b", Dependencies: []vistectureCore.DependencyReference{{Reference: "a"}}},
+	})
+
+	impacted := graph.applyImpacts(map[string]AppDeploymentInfo{
+		"a": {AppStateInfo: AppStateInfo{State: State_failed}},
+		"b": {AppStateInfo: AppStateInfo{State: State_failed}},
+	})
+
+	if !reflect.DeepEqual(impacted["a"].AppStateInfo.ImpactedBy, []string{"b"}) || !reflect.DeepEqual(impacted["b"].AppStateInfo.ImpactedBy, []string{"a"}) {
+		t.Errorf("expected the apps of the cycle to impact each other, got %+v", impacted)
+	}
+}
@@ -2,18 +2,34 @@ package kube
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"regexp"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	apps "k8s.io/api/apps/v1"
 	v1Batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	networkingV1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	appsListers "k8s.io/client-go/listers/apps/v1"
+	batchListers "k8s.io/client-go/listers/batch/v1"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	networkingListers "k8s.io/client-go/listers/networking/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -26,32 +42,129 @@ type (
 		restconfig *rest.Config
 	}
 
-	// KubeInfoServiceInterface delivers information about k8s resources
+	// KubeInfoServiceInterface delivers information about k8s resources.
+	// The resources are indexed by "namespace/name" (see ResourceKey).
 	KubeInfoServiceInterface interface {
+		// GetDefaultNamespace returns the namespace of apps without the k8sNamespace property
+		GetDefaultNamespace() (string, error)
 		GetKubernetesDeployments() (map[string]apps.Deployment, error)
+		GetStatefulSets() (map[string]apps.StatefulSet, error)
+		GetDaemonSets() (map[string]apps.DaemonSet, error)
 		GetIngressesByService() (map[string][]K8sIngressInfo, error)
 		GetServices() (map[string]v1.Service, error)
 		GetConfigMaps() (map[string]v1.ConfigMap, error)
 		GetJobsByApp() (map[string][]v1Batch.Job, error)
+		GetCronJobs() (map[string]v1Batch.CronJob, error)
+		// GetPodsByWorkload returns the pods indexed by the deployment, stateful set or daemon set owning them
+		GetPodsByWorkload() (map[string][]v1.Pod, error)
+		// GetWarningEventsByWorkload returns the most recent Warning events of the workloads, their ReplicaSets, Jobs and pods
+		GetWarningEventsByWorkload() (map[string][]Event, error)
+	}
+
+	// ServiceProxyProvider is implemented by KubeInfoServiceInterface implementations whose services are not reachable directly,
+	// e.g. the ones of another cluster
+	ServiceProxyProvider interface {
+		// GetServiceProxy returns the proxy to call the services through, nil if they are reachable directly
+		GetServiceProxy() (*ServiceProxy, error)
+	}
+
+	// ServiceProxy calls services through the service proxy of the kubernetes API server
+	ServiceProxy struct {
+		Client *http.Client
+		Server *url.URL
+	}
+
+	// DeploymentChangeNotifier is implemented by KubeInfoServiceInterface implementations that watch deployments
+	DeploymentChangeNotifier interface {
+		// OnDeploymentChange registers a listener called with a changed deployment
+		OnDeploymentChange(listener func(deployment apps.Deployment))
 	}
 
-	// KubeInfoService implementation for k8s
+	// KubeInfoService implementation for k8s, reads from a cache kept up to date by shared informers
 	KubeInfoService struct {
 		DemoMode bool
+
+		// kubeContext of the kubeconfig to use, the current context if empty
+		kubeContext string
+		// namespaces to watch, defaults to the namespace of the kubeconfig
+		namespaces []string
+		// allNamespaces watches the whole cluster instead of the namespaces
+		allNamespaces bool
+
+		mu                  sync.Mutex
+		listers             []*kubeListers
+		client              *kubeClient
+		serviceProxy        *ServiceProxy
+		defaultNamespace    string
+		deploymentListeners []func(deployment apps.Deployment)
+
+		// watchFailures holds the informers whose watch is failing, the listers keep serving the outdated cache meanwhile
+		watchMu       sync.Mutex
+		watchFailures map[cache.SharedIndexInformer]*watchFailure
+	}
+
+	// watchFailure is the last error of a failing watch, the watch counts as recovered once the informer synced a new resource version
+	watchFailure struct {
+		err             error
+		since           time.Time
+		resourceVersion string
+	}
+
+	// kubeListers read the resources from the informer caches
+	kubeListers struct {
+		deployments  appsListers.DeploymentLister
+		statefulSets appsListers.StatefulSetLister
+		daemonSets   appsListers.DaemonSetLister
+		replicaSets  appsListers.ReplicaSetLister
+		pods         coreListers.PodLister
+		services     coreListers.ServiceLister
+		configMaps   coreListers.ConfigMapLister
+		jobs         batchListers.JobLister
+		cronJobs     batchListers.CronJobLister
+		ingresses    networkingListers.IngressLister
+		events       coreListers.EventLister
 	}
 )
 
-var _ KubeInfoServiceInterface = &KubeInfoService{}
+// cacheSyncTimeout is the maximum time to wait for the initial fill of the informer caches
+const cacheSyncTimeout = 60 * time.Second
+
+// jobNumberSuffix matches the number generated for the jobs of an app
+var jobNumberSuffix = regexp.MustCompile("(.*)-([0-9]+)")
+
+// watchFailureThreshold is the time a watch may fail until the cache is reported as outdated, short outages are covered by the retries of the informers
+var watchFailureThreshold = 30 * time.Second
+
+var (
+	_ KubeInfoServiceInterface = &KubeInfoService{}
+	_ DeploymentChangeNotifier = &KubeInfoService{}
+	_ ServiceProxyProvider     = &KubeInfoService{}
+)
+
+// NewKubeInfoService creates a KubeInfoService for the kubeContext watching the given namespaces (or all if allNamespaces is set).
+// The first namespace is the default for apps without k8sNamespace property, without namespaces the one of the kubeconfig is used.
+func NewKubeInfoService(kubeContext string, namespaces []string, allNamespaces bool) *KubeInfoService {
+	return &KubeInfoService{
+		kubeContext:   kubeContext,
+		namespaces:    namespaces,
+		allNamespaces: allNamespaces,
+	}
+}
+
+// ResourceKey builds the key used to index the kubernetes resources
+func ResourceKey(namespace, name string) string {
+	return namespace + "/" + name
+}
 
 // KubeClientFromConfig loads a new kubeClient from the usual configuration
-// (KUBECONFIG env param / selfconfigured in kubernetes)
-func KubeClientFromConfig() (*kubeClient, error) {
+// (KUBECONFIG env param / selfconfigured in kubernetes), an empty kubeContext uses the current context
+func KubeClientFromConfig(kubeContext string) (*kubeClient, error) {
 	var client = new(kubeClient)
 	var err error
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 
-	configOverrides := &clientcmd.ConfigOverrides{}
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
 
 	client.kubeconfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 
@@ -74,60 +187,363 @@ func KubeClientFromConfig() (*kubeClient, error) {
 	return client, nil
 }
 
-// GetKubernetesDeployments fetches from Config or Demo Data
-func (k *KubeInfoService) GetKubernetesDeployments() (map[string]apps.Deployment, error) {
-	var deployments *apps.DeploymentList
+// OnDeploymentChange registers a listener called with the deployment whenever its spec or status changes
+func (k *KubeInfoService) OnDeploymentChange(listener func(deployment apps.Deployment)) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.deploymentListeners = append(k.deploymentListeners, listener)
+}
 
-	client, err := KubeClientFromConfig()
+// GetDefaultNamespace returns the first configured namespace or the one of the kubeconfig
+func (k *KubeInfoService) GetDefaultNamespace() (string, error) {
+	if _, err := k.getListers(); err != nil {
+		return "", err
+	}
+
+	return k.defaultNamespace, nil
+}
+
+// GetServiceProxy returns the API server proxy for the services whenever a kubeContext is given, it is not checked whether the context
+// is the cluster the dashboard runs in. Without kubeContext (the current context) it returns nil, the services are called directly.
+func (k *KubeInfoService) GetServiceProxy() (*ServiceProxy, error) {
+	if k.kubeContext == "" {
+		return nil, nil
+	}
+
+	if _, err := k.getListers(); err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.serviceProxy != nil {
+		return k.serviceProxy, nil
+	}
+
+	client, err := rest.HTTPClientFor(k.client.restconfig)
 	if err != nil {
 		return nil, err
 	}
+	server, _, err := rest.DefaultServerUrlFor(k.client.restconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	k.serviceProxy = &ServiceProxy{Client: client, Server: server}
+	return k.serviceProxy, nil
+}
+
+// Target returns the target to check the path of a service port through the proxy
+func (p *ServiceProxy) Target(namespace, service string, port int32, path string) HealthCheckTarget {
+	basePath := fmt.Sprintf("%s/api/v1/namespaces/%s/services/%s:%d/proxy", strings.TrimSuffix(p.Server.Path, "/"), namespace, service, port)
+	if path == "" {
+		// the simple check calls the root of the service
+		basePath += "/"
+	}
 
-	deploymentClient := client.Clientset.AppsV1().Deployments(client.Namespace)
-	deployments, err = deploymentClient.List(context.Background(), metav1.ListOptions{})
+	return HealthCheckTarget{Address: p.Server.Host, Scheme: p.Server.Scheme, BasePath: basePath, Path: path}
+}
+
+// getListers starts the shared informers on first use and waits for the caches to be filled.
+// If this fails it is retried on the next call.
+func (k *KubeInfoService) getListers() ([]*kubeListers, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.listers != nil {
+		if err := k.watchError(); err != nil {
+			return nil, err
+		}
+		return k.listers, nil
+	}
 
+	client, err := KubeClientFromConfig(k.kubeContext)
 	if err != nil {
 		return nil, err
 	}
 
-	deploymentIndex := make(map[string]apps.Deployment, len(deployments.Items))
-	log.Printf("K8s: found %v deployments..\n", len(deployments.Items))
+	namespaces := k.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{client.Namespace}
+	}
+	if k.allNamespaces {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var listers []*kubeListers
+	var stops []chan struct{}
+	for _, namespace := range namespaces {
+		stop := make(chan struct{})
+		namespaceListers, err := k.startInformers(client.Clientset, namespace, stop)
+		if err != nil {
+			// stop the already started informers, all are started again on the next try
+			for _, s := range stops {
+				close(s)
+			}
+			return nil, err
+		}
 
-	for _, deployment := range deployments.Items {
-		deploymentIndex[deployment.Name] = deployment
+		listers = append(listers, namespaceListers)
+		stops = append(stops, stop)
 	}
 
+	k.defaultNamespace = client.Namespace
+	if len(k.namespaces) > 0 {
+		k.defaultNamespace = k.namespaces[0]
+	}
+	k.listers = listers
+	k.client = client
+
+	return k.listers, nil
+}
+
+// startInformers starts the informers for one namespace and waits until their caches are filled
+func (k *KubeInfoService) startInformers(clientset kubernetes.Interface, namespace string, stop chan struct{}) (*kubeListers, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithNamespace(namespace))
+	// only Warning events are of interest, there are lots of Normal ones
+	eventFactory := informers.NewSharedInformerFactoryWithOptions(clientset, 0, informers.WithNamespace(namespace), informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = "type=" + v1.EventTypeWarning
+	}))
+	listers := &kubeListers{
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		statefulSets: factory.Apps().V1().StatefulSets().Lister(),
+		daemonSets:   factory.Apps().V1().DaemonSets().Lister(),
+		replicaSets:  factory.Apps().V1().ReplicaSets().Lister(),
+		pods:         factory.Core().V1().Pods().Lister(),
+		services:     factory.Core().V1().Services().Lister(),
+		configMaps:   factory.Core().V1().ConfigMaps().Lister(),
+		jobs:         factory.Batch().V1().Jobs().Lister(),
+		cronJobs:     factory.Batch().V1().CronJobs().Lister(),
+		ingresses:    factory.Networking().V1().Ingresses().Lister(),
+		events:       eventFactory.Core().V1().Events().Lister(),
+	}
+
+	_, err := factory.Apps().V1().Deployments().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: k.deploymentUpdated,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	informerList := []cache.SharedIndexInformer{
+		factory.Apps().V1().Deployments().Informer(),
+		factory.Apps().V1().StatefulSets().Informer(),
+		factory.Apps().V1().DaemonSets().Informer(),
+		factory.Apps().V1().ReplicaSets().Informer(),
+		factory.Core().V1().Pods().Informer(),
+		factory.Core().V1().Services().Informer(),
+		factory.Core().V1().ConfigMaps().Informer(),
+		factory.Batch().V1().Jobs().Informer(),
+		factory.Batch().V1().CronJobs().Informer(),
+		factory.Networking().V1().Ingresses().Informer(),
+		eventFactory.Core().V1().Events().Informer(),
+	}
+	for _, informer := range informerList {
+		if err := informer.SetWatchErrorHandler(k.watchErrorHandler(informer)); err != nil {
+			return nil, err
+		}
+	}
+
+	factory.Start(stop)
+	eventFactory.Start(stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheSyncTimeout)
+	defer cancel()
+	for _, f := range []informers.SharedInformerFactory{factory, eventFactory} {
+		for informerType, synced := range f.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				close(stop)
+				factory.Shutdown()
+				eventFactory.Shutdown()
+				return nil, fmt.Errorf("could not sync cache for %v in namespace %q", informerType, namespace)
+			}
+		}
+	}
+
+	log.Printf("K8s: started informers for namespace %q\n", namespace)
+	return listers, nil
+}
+
+// watchErrorHandler records the errors of the list and watch calls of the informer, which are retried by the informer without further notice
+func (k *KubeInfoService) watchErrorHandler(informer cache.SharedIndexInformer) cache.WatchErrorHandler {
+	return func(r *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(context.Background(), r, err)
+
+		// watches are closed regularly and resumed by the informer
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			return
+		}
+
+		resourceVersion := informer.LastSyncResourceVersion()
+
+		k.watchMu.Lock()
+		defer k.watchMu.Unlock()
+
+		if k.watchFailures == nil {
+			k.watchFailures = make(map[cache.SharedIndexInformer]*watchFailure)
+		}
+		failure, ok := k.watchFailures[informer]
+		if !ok || failure.resourceVersion != resourceVersion {
+			// the informer synced since the last error, so it is failing again
+			failure = &watchFailure{since: time.Now()}
+			k.watchFailures[informer] = failure
+		}
+		failure.err = err
+		failure.resourceVersion = resourceVersion
+	}
+}
+
+// watchError returns an error if the watch of an informer is failing for longer than watchFailureThreshold
+func (k *KubeInfoService) watchError() error {
+	k.watchMu.Lock()
+	defer k.watchMu.Unlock()
+
+	var failing *watchFailure
+	for informer, failure := range k.watchFailures {
+		if informer.LastSyncResourceVersion() != failure.resourceVersion {
+			// synced again
+			delete(k.watchFailures, informer)
+			continue
+		}
+
+		if time.Since(failure.since) >= watchFailureThreshold && (failing == nil || failure.since.Before(failing.since)) {
+			failing = failure
+		}
+	}
+
+	if failing == nil {
+		return nil
+	}
+
+	return fmt.Errorf("watching kubernetes resources fails since %v, the cache is outdated: %w", failing.since.Format(time.RFC3339), failing.err)
+}
+
+// deploymentUpdated notifies the listeners if the deployment really changed (and not just got resynced)
+func (k *KubeInfoService) deploymentUpdated(oldObj, newObj interface{}) {
+	oldDeployment, ok := oldObj.(*apps.Deployment)
+	if !ok {
+		return
+	}
+	newDeployment, ok := newObj.(*apps.Deployment)
+	if !ok {
+		return
+	}
+
+	if oldDeployment.Generation == newDeployment.Generation && equality.Semantic.DeepEqual(oldDeployment.Status, newDeployment.Status) {
+		return
+	}
+
+	k.mu.Lock()
+	listeners := k.deploymentListeners
+	k.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(*newDeployment)
+	}
+}
+
+// GetKubernetesDeployments fetches from Config or Demo Data
+func (k *KubeInfoService) GetKubernetesDeployments() (map[string]apps.Deployment, error) {
+	listers, err := k.getListers()
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentIndex := make(map[string]apps.Deployment)
+	for _, namespaceListers := range listers {
+		deployments, err := namespaceListers.deployments.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, deployment := range deployments {
+			deploymentIndex[ResourceKey(deployment.Namespace, deployment.Name)] = *deployment
+		}
+	}
+	log.Printf("K8s: found %v deployments..\n", len(deploymentIndex))
+
 	return deploymentIndex, nil
 }
 
-// GetIngressesByService fetches from Config or Demo Data
-func (k *KubeInfoService) GetIngressesByService() (map[string][]K8sIngressInfo, error) {
+// GetStatefulSets fetches the stateful sets of the watched namespaces
+func (k *KubeInfoService) GetStatefulSets() (map[string]apps.StatefulSet, error) {
+	listers, err := k.getListers()
+	if err != nil {
+		return nil, err
+	}
 
-	var ingresses *networkingV1.IngressList
+	statefulSetIndex := make(map[string]apps.StatefulSet)
+	for _, namespaceListers := range listers {
+		statefulSets, err := namespaceListers.statefulSets.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, statefulSet := range statefulSets {
+			statefulSetIndex[ResourceKey(statefulSet.Namespace, statefulSet.Name)] = *statefulSet
+		}
+	}
+	log.Printf("K8s: found %v stateful sets..\n", len(statefulSetIndex))
 
-	client, err := KubeClientFromConfig()
+	return statefulSetIndex, nil
+}
 
+// GetDaemonSets fetches the daemon sets of the watched namespaces
+func (k *KubeInfoService) GetDaemonSets() (map[string]apps.DaemonSet, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
 
-	ingressClient := client.Clientset.NetworkingV1().Ingresses(client.Namespace)
-	ingresses, err = ingressClient.List(context.Background(), metav1.ListOptions{})
+	daemonSetIndex := make(map[string]apps.DaemonSet)
+	for _, namespaceListers := range listers {
+		daemonSets, err := namespaceListers.daemonSets.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, daemonSet := range daemonSets {
+			daemonSetIndex[ResourceKey(daemonSet.Namespace, daemonSet.Name)] = *daemonSet
+		}
+	}
+	log.Printf("K8s: found %v daemon sets..\n", len(daemonSetIndex))
+
+	return daemonSetIndex, nil
+}
+
+// GetIngressesByService fetches from Config or Demo Data
+func (k *KubeInfoService) GetIngressesByService() (map[string][]K8sIngressInfo, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
-	log.Printf("K8s: found %v ingresses..\n", len(ingresses.Items))
 
-	return groupByServiceName(ingresses), nil
+	ingressList := &networkingV1.IngressList{}
+	for _, namespaceListers := range listers {
+		ingresses, err := namespaceListers.ingresses.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ingress := range ingresses {
+			ingressList.Items = append(ingressList.Items, *ingress)
+		}
+	}
+	log.Printf("K8s: found %v ingresses..\n", len(ingressList.Items))
+
+	return groupByServiceName(ingressList), nil
 }
 
+// groupByServiceName indexes the ingresses by namespace and name of their backend service
 func groupByServiceName(ingresses *networkingV1.IngressList) map[string][]K8sIngressInfo {
 	ingressIndex := make(map[string][]K8sIngressInfo)
 	for _, ing := range ingresses.Items {
 		for _, rule := range ing.Spec.Rules {
 			for _, p := range rule.HTTP.Paths {
-				name := p.Backend.Service.Name
-				ingressIndex[name] = append(ingressIndex[name], K8sIngressInfo{URL: rule.Host + p.Path, Host: rule.Host, Path: p.Path})
+				key := ResourceKey(ing.Namespace, p.Backend.Service.Name)
+				ingressIndex[key] = append(ingressIndex[key], K8sIngressInfo{URL: rule.Host + p.Path, Host: rule.Host, Path: p.Path})
 			}
 		}
 	}
@@ -144,84 +560,190 @@ func groupByServiceName(ingresses *networkingV1.IngressList) map[string][]K8sIng
 }
 
 func (k *KubeInfoService) GetServices() (map[string]v1.Service, error) {
+	listers, err := k.getListers()
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIndex := make(map[string]v1.Service)
+	for _, namespaceListers := range listers {
+		services, err := namespaceListers.services.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, service := range services {
+			serviceIndex[ResourceKey(service.Namespace, service.Name)] = *service
+		}
+	}
+	log.Printf("K8s: found %v Services..\n", len(serviceIndex))
 
-	client, err := KubeClientFromConfig()
+	return serviceIndex, nil
+}
 
+func (k *KubeInfoService) GetConfigMaps() (map[string]v1.ConfigMap, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
 
-	serviceClient := client.Clientset.CoreV1().Services(client.Namespace)
-	services, err := serviceClient.List(context.Background(), metav1.ListOptions{})
+	configMapIndex := make(map[string]v1.ConfigMap)
+	for _, namespaceListers := range listers {
+		configMaps, err := namespaceListers.configMaps.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
 
+		for _, configMap := range configMaps {
+			configMapIndex[ResourceKey(configMap.Namespace, configMap.Name)] = *configMap
+		}
+	}
+	log.Printf("K8s: found %v ConfigMaps..\n", len(configMapIndex))
+
+	return configMapIndex, nil
+}
+
+// GetJobsByApp returns the jobs indexed by the name of the CronJob owning them, or their own name if they are not created by a CronJob
+func (k *KubeInfoService) GetJobsByApp() (map[string][]v1Batch.Job, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
 
-	serviceIndex := make(map[string]v1.Service)
-	log.Printf("K8s: found %v Services..\n", len(services.Items))
+	var jobs []*v1Batch.Job
+	for _, namespaceListers := range listers {
+		namespaceJobs, err := namespaceListers.jobs.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, namespaceJobs...)
+	}
 
-	for _, service := range services.Items {
-		serviceIndex[service.Name] = service
+	log.Printf("K8s: found %v Jobs..\n", len(jobs))
 
+	return groupJobsByApp(jobs), nil
+}
+
+// groupJobsByApp indexes the jobs by namespace and the name of their app (see jobAppName),
+// e.g. "akeneo-12345" without owning CronJob is a job of "akeneo"
+func groupJobsByApp(jobs []*v1Batch.Job) map[string][]v1Batch.Job {
+	jobsIndex := make(map[string][]v1Batch.Job)
+	for _, job := range jobs {
+		key := ResourceKey(job.Namespace, jobAppName(job))
+		jobsIndex[key] = append(jobsIndex[key], *job)
 	}
-	return serviceIndex, nil
+	return jobsIndex
 }
 
-func (k *KubeInfoService) GetConfigMaps() (map[string]v1.ConfigMap, error) {
+// jobAppName returns the name of the CronJob owning the job, or the name of the job without the generated number
+func jobAppName(job *v1Batch.Job) string {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return owner.Name
+		}
+	}
 
-	client, err := KubeClientFromConfig()
+	if submatches := jobNumberSuffix.FindStringSubmatch(job.Name); len(submatches) == 3 {
+		return submatches[1]
+	}
+	return job.Name
+}
 
+// GetCronJobs fetches the cron jobs of the watched namespaces
+func (k *KubeInfoService) GetCronJobs() (map[string]v1Batch.CronJob, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
 
-	configMapClient := client.Clientset.CoreV1().ConfigMaps(client.Namespace)
-	configMaps, err := configMapClient.List(context.Background(), metav1.ListOptions{})
+	cronJobIndex := make(map[string]v1Batch.CronJob)
+	for _, namespaceListers := range listers {
+		cronJobs, err := namespaceListers.cronJobs.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
 
+		for _, cronJob := range cronJobs {
+			cronJobIndex[ResourceKey(cronJob.Namespace, cronJob.Name)] = *cronJob
+		}
+	}
+	log.Printf("K8s: found %v CronJobs..\n", len(cronJobIndex))
+
+	return cronJobIndex, nil
+}
+
+// GetPodsByWorkload fetches the pods of the watched namespaces indexed by their deployment, stateful set or daemon set
+func (k *KubeInfoService) GetPodsByWorkload() (map[string][]v1.Pod, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
 
-	configMapIndex := make(map[string]v1.ConfigMap)
-	log.Printf("K8s: found %v ConfigMaps..\n", len(configMaps.Items))
-
-	for _, configMap := range configMaps.Items {
-		configMapIndex[configMap.Name] = configMap
+	var pods []*v1.Pod
+	replicaSetIndex := make(map[string]*apps.ReplicaSet)
+	for _, namespaceListers := range listers {
+		namespacePods, err := namespaceListers.pods.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, namespacePods...)
 
+		replicaSets, err := namespaceListers.replicaSets.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, replicaSet := range replicaSets {
+			replicaSetIndex[ResourceKey(replicaSet.Namespace, replicaSet.Name)] = replicaSet
+		}
 	}
-	return configMapIndex, nil
-}
-
-func (k *KubeInfoService) GetJobsByApp() (map[string][]v1Batch.Job, error) {
+	log.Printf("K8s: found %v Pods..\n", len(pods))
 
-	client, err := KubeClientFromConfig()
+	return groupPodsByWorkload(pods, replicaSetIndex), nil
+}
 
+// GetWarningEventsByWorkload fetches the Warning events of the watched namespaces indexed by the workload they are about
+func (k *KubeInfoService) GetWarningEventsByWorkload() (map[string][]Event, error) {
+	listers, err := k.getListers()
 	if err != nil {
 		return nil, err
 	}
 
-	jobsClient := client.Clientset.BatchV1().Jobs(client.Namespace)
-	jobs, err := jobsClient.List(context.Background(), metav1.ListOptions{})
+	var events []*v1.Event
+	podIndex := make(map[string]*v1.Pod)
+	replicaSetIndex := make(map[string]*apps.ReplicaSet)
+	jobIndex := make(map[string]*v1Batch.Job)
+	for _, namespaceListers := range listers {
+		namespaceEvents, err := namespaceListers.events.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, namespaceEvents...)
 
-	if err != nil {
-		return nil, err
-	}
+		pods, err := namespaceListers.pods.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods {
+			podIndex[ResourceKey(pod.Namespace, pod.Name)] = pod
+		}
 
-	jobsIndex := make(map[string][]v1Batch.Job)
+		replicaSets, err := namespaceListers.replicaSets.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, replicaSet := range replicaSets {
+			replicaSetIndex[ResourceKey(replicaSet.Namespace, replicaSet.Name)] = replicaSet
+		}
 
-	log.Printf("K8s: found %v Jobs..\n", len(jobs.Items))
-	for _, job := range jobs.Items {
-		// Match the jobname to appname (by deleting the last generated number for cronjobs - e.g. "akeneo-12345"  is the last created job for "akeneo")
-		applicationname := job.Name
-		reg := regexp.MustCompile("(.*)-([0-9]+)")
-		submatches := reg.FindStringSubmatch(applicationname)
-		if len(submatches) == 3 {
-			// fmt.Printf("%q\n", submatches)
-			// log.Printf("submatch %v for %v", submatches[1], applicationname)
-			applicationname = submatches[1]
+		jobs, err := namespaceListers.jobs.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range jobs {
+			jobIndex[ResourceKey(job.Namespace, job.Name)] = job
 		}
-		jobsIndex[applicationname] = append(jobsIndex[applicationname], job)
 	}
-	return jobsIndex, nil
+	log.Printf("K8s: found %v Warning events..\n", len(events))
+
+	return groupEventsByWorkload(events, podIndex, replicaSetIndex, jobIndex), nil
 }
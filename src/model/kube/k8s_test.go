@@ -0,0 +1,91 @@
+package kube
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubeInfoService_DeploymentUpdated(t *testing.T) {
+	var changed []string
+	k := &KubeInfoService{}
+	k.OnDeploymentChange(func(deployment apps.Deployment) {
+		changed = append(changed, ResourceKey(deployment.Namespace, deployment.Name))
+	})
+
+	deployment := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "flamingo", Namespace: "frontend", Generation: 1},
+		Status:     apps.DeploymentStatus{AvailableReplicas: 1},
+	}
+
+	// a resync delivers the same object again
+	k.deploymentUpdated(deployment, deployment.DeepCopy())
+	if len(changed) != 0 {
+		t.Errorf("expected no change notification on resync, got %v", changed)
+	}
+
+	scaledDown := deployment.DeepCopy()
+	scaledDown.Status.AvailableReplicas = 0
+	k.deploymentUpdated(deployment, scaledDown)
+	if len(changed) != 1 || changed[0] != "frontend/flamingo" {
+		t.Errorf("expected change notification for frontend/flamingo, got %v", changed)
+	}
+}
+
+func TestKubeInfoService_FailingWatch(t *testing.T) {
+	watchFailureThreshold = 0
+	defer func() { watchFailureThreshold = 30 * time.Second }()
+
+	clientset := fake.NewClientset(&apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "flamingo", Namespace: "shop"}})
+	clientset.PrependWatchReactor("*", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, errors.New("http2: client connection lost")
+	})
+
+	k := NewKubeInfoService("", []string{"shop"}, false)
+	stop := make(chan struct{})
+	listers, err := k.startInformers(clientset, "shop", stop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer close(stop)
+	k.listers = []*kubeListers{listers}
+	k.defaultNamespace = "shop"
+
+	stm := NewStatusFetcher("prod", nil, k)
+	// the cache is filled by the list, the watch fails afterwards
+	deadline := time.Now().Add(5 * time.Second)
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, err = stm.fetchKubernetesResources()
+	}
+	if err == nil {
+		t.Fatal("expected the fetch to fail while the watch is failing")
+	}
+
+	stm.fetchFailed(err)
+	if fetchStatus := stm.GetFetchStatus(); !fetchStatus.Stale() || fetchStatus.ConsecutiveFailures != 1 {
+		t.Errorf("expected a stale result, got %+v", fetchStatus)
+	}
+}
+
+func TestGroupJobsByApp(t *testing.T) {
+	jobs := []*batch.Job{
+		{ObjectMeta: metav1.ObjectMeta{Name: "export-28561020", Namespace: "shop", OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "export"}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "akeneo-12345", Namespace: "shop"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "shop"}},
+	}
+
+	index := groupJobsByApp(jobs)
+	for _, key := range []string{"shop/export", "shop/akeneo", "shop/migration"} {
+		if len(index[key]) != 1 {
+			t.Errorf("expected one job for %v, got %v", key, index[key])
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+type (
+	// PodInfo summarizes the container states of a pod
+	PodInfo struct {
+		Name     string
+		Phase    string
+		Ready    bool
+		Restarts int32
+		// WaitingReason of a container that is not running, e.g. CrashLoopBackOff
+		WaitingReason string
+		// LastTerminationReason of a restarted container, e.g. OOMKilled
+		LastTerminationReason string
+		LastTerminationTime   time.Time
+	}
+)
+
+// Restarts returns the container restarts of all pods of the app
+func (d AppDeploymentInfo) Restarts() int32 {
+	var restarts int32
+	for _, pod := range d.Pods {
+		restarts += pod.Restarts
+	}
+	return restarts
+}
+
+// recentTerminationWindow limits which terminations (e.g. OOMKilled) degrade an app, older ones are just shown
+const recentTerminationWindow = time.Hour
+
+// degradingWaitingReasons are the reasons of waiting containers that need attention
+var degradingWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// groupPodsByWorkload indexes the pods by namespace and name of the deployment, stateful set or daemon set owning them.
+// Pods of deployments are owned by a ReplicaSet, which is resolved by the replicaSets indexed by ResourceKey.
+func groupPodsByWorkload(pods []*v1.Pod, replicaSets map[string]*apps.ReplicaSet) map[string][]v1.Pod {
+	podIndex := make(map[string][]v1.Pod)
+	for _, pod := range pods {
+		if workload := podWorkload(pod, replicaSets); workload != "" {
+			key := ResourceKey(pod.Namespace, workload)
+			podIndex[key] = append(podIndex[key], *pod)
+		}
+	}
+
+	return podIndex
+}
+
+// podWorkload returns the name of the deployment, stateful set or daemon set controlling the pod, empty if there is none
+func podWorkload(pod *v1.Pod, replicaSets map[string]*apps.ReplicaSet) string {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+
+		if owner.Kind == "ReplicaSet" {
+			return replicaSetWorkload(pod.Namespace, owner.Name, replicaSets)
+		}
+		return owner.Name
+	}
+
+	return ""
+}
+
+// replicaSetWorkload returns the name of the deployment owning the replica set, empty if it is not known
+func replicaSetWorkload(namespace, name string, replicaSets map[string]*apps.ReplicaSet) string {
+	replicaSet, ok := replicaSets[ResourceKey(namespace, name)]
+	if !ok {
+		return ""
+	}
+
+	for _, owner := range replicaSet.OwnerReferences {
+		if owner.Kind == "Deployment" {
+			return owner.Name
+		}
+	}
+
+	return ""
+}
+
+// podInfo extracts restarts and the reasons of problems from the container statuses
+func podInfo(pod v1.Pod) PodInfo {
+	info := PodInfo{
+		Name:  pod.Name,
+		Phase: string(pod.Status.Phase),
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			info.Ready = condition.Status == v1.ConditionTrue
+		}
+	}
+
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		info.Restarts += status.RestartCount
+		if status.State.Waiting != nil && degradingWaitingReasons[status.State.Waiting.Reason] && info.WaitingReason == "" {
+			info.WaitingReason = status.State.Waiting.Reason
+		}
+		if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.FinishedAt.After(info.LastTerminationTime) {
+			info.LastTerminationReason = terminated.Reason
+			info.LastTerminationTime = terminated.FinishedAt.Time
+		}
+	}
+
+	return info
+}
+
+// podInfos returns the infos of the pods ordered by name
+func podInfos(pods []v1.Pod) []PodInfo {
+	infos := make([]PodInfo, 0, len(pods))
+	for _, pod := range pods {
+		infos = append(infos, podInfo(pod))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+
+	return infos
+}
+
+// degradedReasons returns why an app with available pods still needs attention, nothing if all is fine
+func degradedReasons(workload WorkloadStatus, pods []PodInfo, now time.Time) []string {
+	var reasons []string
+	if workload.Available < workload.Desired {
+		reasons = append(reasons, fmt.Sprintf("Only %d of %d replicas available", workload.Available, workload.Desired))
+	}
+
+	waiting := make(map[string][]string)
+	var oomKilled []string
+	for _, pod := range pods {
+		if pod.WaitingReason != "" {
+			waiting[pod.WaitingReason] = append(waiting[pod.WaitingReason], pod.Name)
+		}
+		if pod.LastTerminationReason == "OOMKilled" && now.Sub(pod.LastTerminationTime) < recentTerminationWindow {
+			oomKilled = append(oomKilled, pod.Name)
+		}
+	}
+
+	waitingReasons := make([]string, 0, len(waiting))
+	for reason := range waiting {
+		waitingReasons = append(waitingReasons, reason)
+	}
+	sort.Strings(waitingReasons)
+	for _, reason := range waitingReasons {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", reason, strings.Join(waiting[reason], ", ")))
+	}
+
+	if len(oomKilled) > 0 {
+		reasons = append(reasons, "OOMKilled: "+strings.Join(oomKilled, ", "))
+	}
+
+	return reasons
+}
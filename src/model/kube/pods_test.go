@@ -0,0 +1,83 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGroupPodsByWorkload(t *testing.T) {
+	controller := true
+	pod := func(name, ownerKind, ownerName string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "shop",
+			OwnerReferences: []metav1.OwnerReference{{Kind: ownerKind, Name: ownerName, Controller: &controller}},
+		}}
+	}
+
+	replicaSets := map[string]*apps.ReplicaSet{
+		"shop/flamingo-5f6d8": {ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "flamingo"}}}},
+	}
+	pods := []*v1.Pod{
+		pod("flamingo-5f6d8-a", "ReplicaSet", "flamingo-5f6d8"),
+		pod("flamingo-5f6d8-b", "ReplicaSet", "flamingo-5f6d8"),
+		pod("elasticsearch-0", "StatefulSet", "elasticsearch"),
+		pod("orphan-abc", "ReplicaSet", "orphan"),
+	}
+
+	index := groupPodsByWorkload(pods, replicaSets)
+	if len(index["shop/flamingo"]) != 2 || len(index["shop/elasticsearch"]) != 1 || len(index) != 2 {
+		t.Errorf("unexpected pod index %v", index)
+	}
+}
+
+func TestDegradedReasons(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	pods := podInfos([]v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "flamingo-b"},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+				RestartCount: 7,
+				State:        v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "flamingo-a"},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+				RestartCount:         1,
+				State:                v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-5 * time.Minute))}},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "flamingo-c"},
+			Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+				RestartCount:         1,
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-5 * time.Hour))}},
+			}}},
+		},
+	})
+
+	reasons := degradedReasons(WorkloadStatus{Desired: 3, Available: 2}, pods, now)
+	expected := []string{
+		"Only 2 of 3 replicas available",
+		"CrashLoopBackOff: flamingo-b",
+		"OOMKilled: flamingo-a",
+	}
+	if !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected %q, got %q", expected, reasons)
+	}
+
+	if restarts := (AppDeploymentInfo{Pods: pods}).Restarts(); restarts != 9 {
+		t.Errorf("expected 9 restarts, got %d", restarts)
+	}
+
+	if reasons := degradedReasons(WorkloadStatus{Desired: 3, Available: 3}, pods[2:], now); len(reasons) != 0 {
+		t.Errorf("expected an old OOMKill not to degrade, got %q", reasons)
+	}
+}
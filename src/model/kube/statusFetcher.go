@@ -1,10 +1,10 @@
 package kube
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
 	"log"
+	"maps"
 	"math/rand"
 	"net/http"
 	"slices"
@@ -18,34 +18,181 @@ import (
 	apps "k8s.io/api/apps/v1"
 	v1Batch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/history"
 )
 
 type (
 	StatusFetcher struct {
-		mu                    *sync.RWMutex
+		environment string
+		mu          *sync.RWMutex
+		// apps holds the results with the impacts of broken dependencies applied, ownResults the results of the checks of the apps themselves
 		apps                  map[string]AppDeploymentInfo
+		ownResults            map[string]AppDeploymentInfo
 		definedVistectureApps []*vistectureCore.Application
+		dependencies          dependencyGraph
 		KubeInfoService       KubeInfoServiceInterface
+		subscribers           map[chan StatusUpdate]struct{}
+		listeners             []func(update StatusUpdate)
+		lastResults           map[string][]AppDeploymentInfo
+		rechecking            map[string]bool
+		// resources are the kubernetes resources of the last fetch, pendingRechecks the changed deployments waiting for their recheck
+		resources       *kubernetesResources
+		pendingRechecks map[string]*pendingRecheck
+		// checkStarted is the start of the check of the stored result of each app
+		checkStarted    map[string]time.Time
+		ignoredServices []string
+		fetchStatus     FetchStatus
+		// healthChecks are the checkers of the apps, built once from their properties
+		healthChecks map[string]*appHealthCheck
+
+		// CheckConcurrency is the number of apps checked at the same time, CheckJitter the maximum random wait before each check
+		// and CheckTimeout the time the check of an app may take, including the check through the ingress
+		CheckConcurrency int
+		CheckJitter      time.Duration
+		CheckTimeout     time.Duration
+		// CronJobMissedPeriods is the number of schedule periods without successful run after which a CronJob is reported as missed
+		CronJobMissedPeriods int
+		// History persists the state changes of the apps if set, recorded holds the last record of each app
+		History  history.Store
+		recorded map[string]history.Record
+		// pendingChecks are the check results to be stored by the scheduled roll-up
+		pendingChecks   []history.CheckResult
+		rollUpScheduled bool
+		// availabilityUpdated is the time the availability metrics were calculated last
+		availabilityUpdated time.Time
+	}
+
+	// pendingRecheck is a changed deployment, further changes reset the timer of its recheck
+	pendingRecheck struct {
+		deployment apps.Deployment
+		timer      *time.Timer
+	}
+
+	// FetchStatus describes the outcome of fetching the kubernetes resources
+	FetchStatus struct {
+		LastSuccess         time.Time
+		LastError           string
+		FailingSince        time.Time
+		ConsecutiveFailures int
+	}
+
+	// kubernetesResources holds the k8s resources of an environment indexed by namespace/name
+	kubernetesResources struct {
+		environment      string
+		defaultNamespace string
+		deployments      map[string]apps.Deployment
+		statefulSets     map[string]apps.StatefulSet
+		daemonSets       map[string]apps.DaemonSet
+		services         map[string]v1.Service
+		ingresses        map[string][]K8sIngressInfo
+		jobs             map[string][]v1Batch.Job
+		cronJobs         map[string]v1Batch.CronJob
+		pods             map[string][]v1.Pod
+		events           map[string][]Event
+		configMaps       map[string]v1.ConfigMap
+		// serviceProxy is used to call the services if they are not reachable directly
+		serviceProxy *ServiceProxy
+
+		// cronJobMissedPeriods and healthChecks are taken from the StatusFetcher
+		cronJobMissedPeriods int
+		healthChecks         map[string]*appHealthCheck
+	}
+
+	// StatusUpdate is published to the subscribers for the results of the apps as they are checked and at the end of each fetch cycle
+	StatusUpdate struct {
+		Time        time.Time
+		FetchStatus FetchStatus
+		// Cycle is set for the update at the end of a fetch cycle, also a failed one, the others contain the results of single apps
+		Cycle bool
+		// Changed contains the apps whose state or state reason changed
+		Changed []AppDeploymentInfo
+		// Transitions are the changes of the state of the apps checked before, e.g. from healthy to failed
+		Transitions []StateTransition
+	}
+
+	// StateTransition is a change of the state of an app from Previous to the state of App.
+	// PreviousOwnState is the state of the app's own check before, it differs from Previous if the app was impacted.
+	StateTransition struct {
+		Previous         uint
+		PreviousOwnState uint
+		App              AppDeploymentInfo
 	}
 
 	// AppDeploymentInfo wraps Info on any Deployment's Data
 	AppDeploymentInfo struct {
-		Name                string
-		Labels              map[string]string
-		Ingress             []K8sIngressInfo
-		Images              []Image
-		K8sDeployment       apps.Deployment
-		AppStateInfo        AppStateInfo
-		HealthcheckPath     string
+		Name        string
+		Namespace   string
+		Environment string
+		// K8sType is the kind of kubernetes workload checked for the app (see K8sType_*)
+		K8sType        string
+		Labels         map[string]string
+		Ingress        []K8sIngressInfo
+		Images         []Image
+		K8sDeployment  apps.Deployment
+		K8sStatefulSet apps.StatefulSet
+		K8sDaemonSet   apps.DaemonSet
+		Workload       WorkloadStatus
+		Pods           []PodInfo
+		// Events are the most recent Warning events, the newest first
+		Events []Event
+		// CronJob is set for apps checked by a kubernetes CronJob
+		CronJob         *CronJobInfo
+		AppStateInfo    AppStateInfo
+		HealthcheckPath string
+		// HealthCheckServices are the dependencies reported by the healthcheck of the app
+		HealthCheckServices []HealthCheckService
 		ApiDocumentationUrl string
 		VistectureApp       vistectureCore.Application
 	}
 
+	// WorkloadStatus is the replica status of a deployment, stateful set or daemon set
+	WorkloadStatus struct {
+		// Desired is the number of replicas (or scheduled pods of a daemon set) that should run, Current the number of pods created
+		Desired            int32
+		Current            int32
+		Ready              int32
+		Available          int32
+		ObservedGeneration int64
+		Conditions         []WorkloadCondition
+	}
+
+	// CronJobInfo describes the schedule and the recent runs of a CronJob
+	CronJobInfo struct {
+		Schedule           string
+		TimeZone           string
+		Suspended          bool
+		ActiveJobs         int
+		LastScheduleTime   time.Time
+		LastSuccessfulTime time.Time
+		NextScheduleTime   time.Time
+		// MissedSchedule is set if there was no successful run within the configured number of schedule periods, the app is degraded then
+		MissedSchedule bool
+	}
+
+	// WorkloadCondition is a condition of the workload, e.g. "Available"
+	WorkloadCondition struct {
+		Type    string
+		Status  string
+		Message string
+	}
+
 	AppStateInfo struct {
-		State                  uint
-		StateReason            string
+		State       uint
+		StateReason string
+		// OwnState is the state of the app's own check if it is impacted by the root causes in ImpactedBy
+		OwnState   uint
+		ImpactedBy []string
+		// Impacts lists the apps impacted by this app, if it is a likely root cause
+		Impacts                []string
 		HealthCheckType        string
 		HealthyAlsoFromIngress bool
+		CheckedAt              time.Time
+		// CheckDuration is the duration of the health check of the service, IngressCheckDuration the one through the ingress
+		CheckDuration        time.Duration
+		IngressCheckDuration time.Duration
+		// RecentCheckDurations are the durations of the recent checks, oldest first
+		RecentCheckDurations []time.Duration
 	}
 
 	Image struct {
@@ -83,6 +230,7 @@ var (
 	}, []string{
 		"application",
 		"team",
+		"environment",
 	})
 
 	healthcheckDependencies = prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -92,15 +240,61 @@ var (
 		"application",
 		"dependency",
 		"team",
+		"environment",
+	})
+
+	teamHealthcheck = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "team_health_status",
+		Help: "Worst Application Healthcheck Status of the team",
+	}, []string{
+		"team",
+		"environment",
+	})
+
+	teamApplications = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "team_applications",
+		Help: "Number of applications of the team per state",
+	}, []string{
+		"team",
+		"state",
+		"environment",
+	})
+
+	healthcheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "application_health_check_duration_seconds",
+		Help:    "Duration of the Application Healthchecks",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 15},
+	}, []string{
+		"application",
+		"team",
+		"environment",
+		"check",
+	})
+
+	fetchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubernetes_fetch_failures_total",
+		Help: "Failed fetches of the kubernetes resources",
+	}, []string{
+		"environment",
 	})
 
 	httpClient = &http.Client{}
+
+	// recheckDelay is the time a changed deployment has to stay unchanged before its apps are rechecked, a rollout changes it several times
+	recheckDelay = 2 * time.Second
+
+	// rollUpDelay is the time the results of the checks are collected before the impacts on other apps, the team metrics and the history are updated
+	rollUpDelay = time.Second
 )
 
 func init() {
 	// Metrics have to be registered to be exposed:
 	prometheus.MustRegister(healthcheck)
 	prometheus.MustRegister(healthcheckDependencies)
+	prometheus.MustRegister(healthcheckDuration)
+	prometheus.MustRegister(teamHealthcheck)
+	prometheus.MustRegister(teamApplications)
+	prometheus.MustRegister(fetchFailures)
 
 	httpClient.Timeout = 15 * time.Second
 }
@@ -112,32 +306,129 @@ const (
 	State_healthy
 	State_unstable
 	State_ignored
+	State_degraded
+	State_impacted
 )
 
+// stateNames maps the states to their names used in the api and the templates
+var stateNames = map[uint]string{
+	State_unknown:   "unknown",
+	State_failed:    "failed",
+	State_unhealthy: "unhealthy",
+	State_healthy:   "healthy",
+	State_unstable:  "unstable",
+	State_ignored:   "ignored",
+	State_degraded:  "degraded",
+	State_impacted:  "impacted",
+}
+
+// stateSeverities orders the states from good to bad
+var stateSeverities = map[uint]int{
+	State_ignored:   0,
+	State_healthy:   1,
+	State_unknown:   2,
+	State_unstable:  3,
+	State_degraded:  4,
+	State_impacted:  5,
+	State_unhealthy: 6,
+	State_failed:    7,
+}
+
 const (
 	// This is the Interval for goroutine polling of kubernetes
 	refreshInterval = 15
 
+	// retryInitialBackoff and retryMaxBackoff limit the wait time between retries if kubernetes is unavailable
+	retryInitialBackoff = 1 * time.Second
+	retryMaxBackoff     = 2 * time.Minute
+
+	// defaultCheckConcurrency is the number of apps checked at the same time if CheckConcurrency is not set
+	defaultCheckConcurrency = 10
+
 	HealthCheckType_NotCheckedYet = ""
 	HealthCheckType_SimpleCheck   = "simple"
 	HealthCheckType_HealthCheck   = "healthcheck"
 	HealthCheckType_Job           = "job"
+	HealthCheckType_TCP           = "tcp"
+	HealthCheckType_GRPC          = "grpc"
+
+	// values of the k8sType property
+	K8sType_Deployment  = "deployment"
+	K8sType_StatefulSet = "statefulset"
+	K8sType_DaemonSet   = "daemonset"
+	K8sType_Job         = "job"
+	K8sType_CronJob     = "cronjob"
+
+	// defaultCronJobMissedPeriods is used if StatusFetcher.CronJobMissedPeriods is not set
+	defaultCronJobMissedPeriods = 2
 )
 
-func NewStatusFetcher(apps []*vistectureCore.Application, demoMode bool, fakeHealthcheckPort int32) *StatusFetcher {
+// k8sKinds maps the k8sType values to the kubernetes kind
+var k8sKinds = map[string]string{
+	K8sType_Deployment:  "Deployment",
+	K8sType_StatefulSet: "StatefulSet",
+	K8sType_DaemonSet:   "DaemonSet",
+	K8sType_Job:         "Job",
+	K8sType_CronJob:     "CronJob",
+}
+
+// StateName returns the name of a state (e.g. "failed")
+func StateName(state uint) string {
+	if name, ok := stateNames[state]; ok {
+		return name
+	}
+	return stateNames[State_unknown]
+}
+
+// StateSeverity returns how bad a state is, e.g. to find the worst state of a team
+func StateSeverity(state uint) int {
+	return stateSeverities[state]
+}
+
+// StateByName returns the state for a given name, the bool is false if the name is unknown
+func StateByName(name string) (uint, bool) {
+	for state, stateName := range stateNames {
+		if strings.EqualFold(stateName, name) {
+			return state, true
+		}
+	}
+	return State_unknown, false
+}
+
+// OwnCheckState returns the state of the app's own check, which is the OwnState if the app is impacted
+func (a AppStateInfo) OwnCheckState() uint {
+	if a.State == State_impacted {
+		return a.OwnState
+	}
+	return a.State
+}
+
+// NewStatusFetcher creates a StatusFetcher checking the apps in an environment with the resources from the kubeInfoService
+func NewStatusFetcher(environment string, apps []*vistectureCore.Application, kubeInfoService KubeInfoServiceInterface) *StatusFetcher {
 	statusManager := new(StatusFetcher)
+	statusManager.environment = environment
 	statusManager.mu = new(sync.RWMutex)
 	statusManager.apps = make(map[string]AppDeploymentInfo)
+	statusManager.ownResults = make(map[string]AppDeploymentInfo)
+	statusManager.subscribers = make(map[chan StatusUpdate]struct{})
+	statusManager.lastResults = make(map[string][]AppDeploymentInfo)
+	statusManager.checkStarted = make(map[string]time.Time)
+	statusManager.rechecking = make(map[string]bool)
+	statusManager.pendingRechecks = make(map[string]*pendingRecheck)
+	statusManager.recorded = make(map[string]history.Record)
 	statusManager.definedVistectureApps = apps
-	if demoMode {
-		statusManager.KubeInfoService = &DemoService{fakeHealthcheckPort: fakeHealthcheckPort}
-	} else {
-		statusManager.KubeInfoService = &KubeInfoService{}
-	}
+	statusManager.dependencies = newDependencyGraph(apps)
+	statusManager.healthChecks = newAppHealthChecks(apps)
+	statusManager.KubeInfoService = kubeInfoService
 
 	return statusManager
 }
 
+// Environment returns the name of the checked environment
+func (stm *StatusFetcher) Environment() string {
+	return stm.environment
+}
+
 func (stm *StatusFetcher) GetCurrentResult() map[string]AppDeploymentInfo {
 	stm.mu.RLock()
 
@@ -152,161 +443,614 @@ func (stm *StatusFetcher) GetCurrentResult() map[string]AppDeploymentInfo {
 	return result
 }
 
-// FetchStatusInRegularInterval controls the interval in which new info is fetched and loops over configured applications
-func (stm *StatusFetcher) FetchStatusInRegularInterval(ignoredServices []string) {
-	var tickIteration = 0
-	lastResults := make(map[string][]AppDeploymentInfo)
-	fetcher := func() {
-		// Add Deployments to Dashboard
-		k8sDeployments, err := stm.KubeInfoService.GetKubernetesDeployments()
+// GetFetchStatus returns the outcome of the last fetch of the kubernetes resources
+func (stm *StatusFetcher) GetFetchStatus() FetchStatus {
+	stm.mu.RLock()
+	defer stm.mu.RUnlock()
 
-		if err != nil {
-			panic("Could not get Deployment Config, check Configuration and Kubernetes Connection: " + err.Error())
+	return stm.fetchStatus
+}
+
+// Stale is true if the last fetch failed and the current result is outdated
+func (f FetchStatus) Stale() bool {
+	return f.LastError != ""
+}
+
+// Age returns the time since the last successful fetch
+func (f FetchStatus) Age() time.Duration {
+	if f.LastSuccess.IsZero() {
+		return 0
+	}
+	return time.Since(f.LastSuccess).Truncate(time.Second)
+}
+
+// Subscribe returns a channel that receives every StatusUpdate, the results of the apps as they are checked and a Cycle update at the end of each fetch cycle.
+// A subscriber that does not keep up is unsubscribed and its channel closed, so it does not miss updates silently but has to subscribe and take a snapshot again.
+// The returned func has to be called to unsubscribe, it closes the channel.
+func (stm *StatusFetcher) Subscribe() (<-chan StatusUpdate, func()) {
+	// the results of the apps are published one by one, so the updates of a cycle come in bursts
+	updates := make(chan StatusUpdate, 100)
+
+	stm.mu.Lock()
+	stm.subscribers[updates] = struct{}{}
+	stm.mu.Unlock()
+
+	return updates, func() {
+		stm.mu.Lock()
+		if _, ok := stm.subscribers[updates]; ok {
+			delete(stm.subscribers, updates)
+			close(updates)
 		}
+		stm.mu.Unlock()
+	}
+}
 
-		configMaps, err := stm.KubeInfoService.GetConfigMaps()
-		if err != nil {
-			panic("Could not get Config Maps, check Configuration and Kubernetes Connection: " + err.Error())
+// OnUpdate registers a listener receiving every StatusUpdate, unlike a subscriber it never misses one.
+// The listener is called in order while the fetcher holds its lock, so it has to return quickly and must not call the fetcher.
+func (stm *StatusFetcher) OnUpdate(listener func(update StatusUpdate)) {
+	stm.mu.Lock()
+	defer stm.mu.Unlock()
+
+	stm.listeners = append(stm.listeners, listener)
+}
+
+// publish passes the update to the listeners and sends it to all subscribers, a subscriber that would block the fetcher is unsubscribed instead.
+// The caller has to hold the write lock, so the updates are published in the order they were made.
+func (stm *StatusFetcher) publish(update StatusUpdate) {
+	for _, listener := range stm.listeners {
+		listener(update)
+	}
+
+	for subscriber := range stm.subscribers {
+		select {
+		case subscriber <- update:
+		default:
+			log.Println("StatusFetcher: subscriber is not consuming updates, closing it to have it resubscribe")
+			delete(stm.subscribers, subscriber)
+			close(subscriber)
 		}
+	}
+}
+
+// FetchStatusInRegularInterval controls the interval in which new info is fetched and loops over configured applications
+func (stm *StatusFetcher) FetchStatusInRegularInterval(ignoredServices []string) {
+	stm.mu.Lock()
+	stm.ignoredServices = ignoredServices
+	stm.mu.Unlock()
+	stm.restoreHistory()
+
+	// check apps immediately when their deployment changes
+	if notifier, ok := stm.KubeInfoService.(DeploymentChangeNotifier); ok {
+		notifier.OnDeploymentChange(stm.recheckDeployment)
+	}
 
-		// Add Ingresses
-		ingresses, err := stm.KubeInfoService.GetIngressesByService()
+	fetcher := func() error {
+		resources, err := stm.fetchKubernetesResources()
 		if err != nil {
-			panic("Could not get Ingress Config, check Configuration and Kubernetes Connection" + err.Error())
+			stm.fetchFailed(err)
+			return err
 		}
 
-		// Add Services
-		services, err := stm.KubeInfoService.GetServices()
-		if err != nil {
-			panic("Could not get Ingress Config, check Configuration and Kubernetes Connection" + err.Error())
+		stm.mu.Lock()
+		stm.fetchStatus = FetchStatus{LastSuccess: time.Now()}
+		stm.resources = resources
+		stm.mu.Unlock()
+
+		stm.checkApps(ignoredServices, resources)
+		return nil
+	}
+
+	// keep the last results if kubernetes is not reachable and retry with exponential backoff
+	backoff := retryInitialBackoff
+	for {
+		if err := fetcher(); err != nil {
+			log.Printf("Fetching kubernetes resources failed, retry in %v: %v", backoff, err)
+			time.Sleep(backoff)
+			backoff = min(backoff*2, retryMaxBackoff)
+			continue
 		}
 
-		// Add Jobs
-		jobs, err := stm.KubeInfoService.GetJobsByApp()
+		backoff = retryInitialBackoff
+		time.Sleep(refreshInterval * time.Second)
+	}
+}
+
+// fetchFailed marks the current result as stale
+func (stm *StatusFetcher) fetchFailed(err error) {
+	fetchFailures.With(prometheus.Labels{"environment": stm.environment}).Inc()
+	update := StatusUpdate{Time: time.Now(), Cycle: true}
+
+	stm.mu.Lock()
+	if stm.fetchStatus.ConsecutiveFailures == 0 {
+		stm.fetchStatus.FailingSince = update.Time
+	}
+	stm.fetchStatus.ConsecutiveFailures++
+	stm.fetchStatus.LastError = err.Error()
+	update.FetchStatus = stm.fetchStatus
+	stm.publish(update)
+	stm.mu.Unlock()
+}
+
+// fetchKubernetesResources gets all resources needed to check the apps
+func (stm *StatusFetcher) fetchKubernetesResources() (*kubernetesResources, error) {
+	var err error
+	resources := &kubernetesResources{environment: stm.environment, cronJobMissedPeriods: stm.CronJobMissedPeriods, healthChecks: stm.healthChecks}
+	if resources.cronJobMissedPeriods < 1 {
+		resources.cronJobMissedPeriods = defaultCronJobMissedPeriods
+	}
+
+	resources.defaultNamespace, err = stm.KubeInfoService.GetDefaultNamespace()
+	if err != nil {
+		return nil, fmt.Errorf("could not get default namespace, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	// Add Deployments to Dashboard
+	resources.deployments, err = stm.KubeInfoService.GetKubernetesDeployments()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Deployment Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	resources.statefulSets, err = stm.KubeInfoService.GetStatefulSets()
+	if err != nil {
+		return nil, fmt.Errorf("could not get StatefulSet Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	resources.daemonSets, err = stm.KubeInfoService.GetDaemonSets()
+	if err != nil {
+		return nil, fmt.Errorf("could not get DaemonSet Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	resources.configMaps, err = stm.KubeInfoService.GetConfigMaps()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Config Maps, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	// Add Ingresses
+	resources.ingresses, err = stm.KubeInfoService.GetIngressesByService()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Ingress Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	// Add Services
+	resources.services, err = stm.KubeInfoService.GetServices()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Service Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	// Add Jobs
+	resources.jobs, err = stm.KubeInfoService.GetJobsByApp()
+	if err != nil {
+		return nil, fmt.Errorf("could not get jobs Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	resources.cronJobs, err = stm.KubeInfoService.GetCronJobs()
+	if err != nil {
+		return nil, fmt.Errorf("could not get CronJob Config, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	resources.pods, err = stm.KubeInfoService.GetPodsByWorkload()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Pods, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	resources.events, err = stm.KubeInfoService.GetWarningEventsByWorkload()
+	if err != nil {
+		return nil, fmt.Errorf("could not get Events, check Configuration and Kubernetes Connection: %w", err)
+	}
+
+	if provider, ok := stm.KubeInfoService.(ServiceProxyProvider); ok {
+		resources.serviceProxy, err = provider.GetServiceProxy()
 		if err != nil {
-			panic("Could not get jobs Config, check Configuration and Kubernetes Connection" + err.Error())
+			return nil, fmt.Errorf("could not create the Service Proxy, check Configuration and Kubernetes Connection: %w", err)
 		}
+	}
 
-		tickIteration++
+	return resources, nil
+}
 
-		// results is a list of channels, which get filled by the fetcher
-		var results []chan AppDeploymentInfo
+// recheckDeployment checks all apps using the deployment right away instead of waiting for the next tick.
+// Changes in quick succession are rechecked once, after the deployment did not change for recheckDelay.
+func (stm *StatusFetcher) recheckDeployment(deployment apps.Deployment) {
+	key := ResourceKey(deployment.Namespace, deployment.Name)
 
-		for _, app := range stm.definedVistectureApps {
-			// Deployment is not on Kubernetes
-			if di, ok := app.Properties["deployment"]; !ok || di != "kubernetes" {
-				continue
-			}
-			// wait a bit between healthchecks to not do them all at once
-			millisecondsToWait := rand.Intn(700) + 300
-			time.Sleep(time.Millisecond * time.Duration(millisecondsToWait))
+	stm.mu.Lock()
+	defer stm.mu.Unlock()
 
-			results = append(results, checkAppStatusInKubernetes(ignoredServices, app, k8sDeployments, services, ingresses, jobs, configMaps))
+	if pending, ok := stm.pendingRechecks[key]; ok {
+		pending.deployment = deployment
+		pending.timer.Reset(recheckDelay)
+		return
+	}
+
+	stm.pendingRechecks[key] = &pendingRecheck{
+		deployment: deployment,
+		timer: time.AfterFunc(recheckDelay, func() {
+			stm.recheckPending(key)
+		}),
+	}
+}
+
+// recheckPending checks the apps of a changed deployment with the resources of the last fetch,
+// the pods and events of the deployment are read again as they change with the deployment
+func (stm *StatusFetcher) recheckPending(key string) {
+	stm.mu.Lock()
+	pending, ok := stm.pendingRechecks[key]
+	delete(stm.pendingRechecks, key)
+	lastResources := stm.resources
+	stm.mu.Unlock()
+
+	if !ok || lastResources == nil {
+		return
+	}
+
+	pods, err := stm.KubeInfoService.GetPodsByWorkload()
+	if err != nil {
+		log.Printf("StatusFetcher: could not get the pods to recheck %v: %v", key, err)
+		return
+	}
+	events, err := stm.KubeInfoService.GetWarningEventsByWorkload()
+	if err != nil {
+		log.Printf("StatusFetcher: could not get the events to recheck %v: %v", key, err)
+		return
+	}
+
+	// work on a copy, the last resources might be used by the checks of the current cycle
+	resources := *lastResources
+	resources.deployments = withEntry(lastResources.deployments, key, pending.deployment)
+	resources.pods = withEntry(lastResources.pods, key, pods[key])
+	resources.events = withEntry(lastResources.events, key, events[key])
+
+	for _, app := range stm.definedVistectureApps {
+		if !isKubernetesApp(app) || appK8sType(app) != K8sType_Deployment {
+			continue
+		}
+
+		name := app.Name
+		if n, ok := app.Properties["k8sDeploymentName"]; ok && n != "" {
+			name = n
+		}
+
+		if ResourceKey(appNamespace(app, resources.defaultNamespace), name) == key {
+			go stm.recheckApp(app, &resources)
 		}
+	}
+}
+
+// withEntry returns a copy of m with the entry of key set to value
+func withEntry[V any](m map[string]V, key string, value V) map[string]V {
+	c := make(map[string]V, len(m)+1)
+	maps.Copy(c, m)
+	c[key] = value
+	return c
+}
 
-		// exclusive lock map for write access
+// recheckApp checks a single app, concurrent rechecks of the same app are skipped
+func (stm *StatusFetcher) recheckApp(app *vistectureCore.Application, resources *kubernetesResources) {
+	stm.mu.Lock()
+	if stm.rechecking[app.Name] {
+		stm.mu.Unlock()
+		return
+	}
+	stm.rechecking[app.Name] = true
+	ignoredServices := stm.ignoredServices
+	stm.mu.Unlock()
+
+	defer func() {
 		stm.mu.Lock()
+		delete(stm.rechecking, app.Name)
+		stm.mu.Unlock()
+	}()
 
-		// read all results in to map
-		for _, result := range results {
-			// get result from future
-			status := <-result
+	stm.checkApp(ignoredServices, app, resources)
+}
 
-			// prepend status to list of last results
-			lastResults[status.Name] = append([]AppDeploymentInfo{status}, lastResults[status.Name]...)
-			if len(lastResults[status.Name]) > 20 {
-				// limit to 20
-				lastResults[status.Name] = lastResults[status.Name][:20]
-			}
+// checkApps checks the kubernetes apps with CheckConcurrency workers, every result is published as soon as the check of the app is done
+// and a Cycle update once all apps are checked
+func (stm *StatusFetcher) checkApps(ignoredServices []string, resources *kubernetesResources) {
+	concurrency := stm.CheckConcurrency
+	if concurrency < 1 {
+		concurrency = defaultCheckConcurrency
+	}
 
-			countRecentUnstable := 0
-			var recentIssues []string
-			// mark as unstable if in last was a failure
-			if status.AppStateInfo.State == State_healthy {
-				for _, lastStatus := range lastResults[status.Name] {
-					if lastStatus.AppStateInfo.State == State_failed || lastStatus.AppStateInfo.State == State_unhealthy {
-						countRecentUnstable++
-						recentIssues = append(recentIssues, lastStatus.AppStateInfo.StateReason)
-					}
+	apps := make(chan *vistectureCore.Application)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for app := range apps {
+				// wait a bit between healthchecks to not do them all at once
+				if stm.CheckJitter > 0 {
+					time.Sleep(time.Duration(rand.Int63n(int64(stm.CheckJitter))))
 				}
+				stm.checkApp(ignoredServices, app, resources)
 			}
+		}()
+	}
 
-			if countRecentUnstable > 0 {
-				status.AppStateInfo.State = State_unstable
-				status.AppStateInfo.StateReason = fmt.Sprintf(
-					"Failed %d out of %d checks in the last %d seconds\n%s",
-					countRecentUnstable,
-					len(lastResults[status.Name]),
-					len(lastResults[status.Name])*refreshInterval,
-					strings.Join(recentIssues, "\n"),
-				)
-			}
+	for _, app := range stm.definedVistectureApps {
+		// Deployment is not on Kubernetes
+		if isKubernetesApp(app) {
+			apps <- app
+		}
+	}
+	close(apps)
+	wg.Wait()
+
+	update := StatusUpdate{Time: time.Now(), Cycle: true}
+	stm.mu.Lock()
+	update.FetchStatus = stm.fetchStatus
+	stm.publish(update)
+	stm.mu.Unlock()
+}
 
-			stm.apps[status.Name] = status
-			switch status.AppStateInfo.State {
-			case State_healthy, State_ignored:
-				healthcheck.With(prometheus.Labels{"application": status.Name, "team": status.VistectureApp.Team}).Set(0)
-			case State_unhealthy, State_unstable:
-				healthcheck.With(prometheus.Labels{"application": status.Name, "team": status.VistectureApp.Team}).Set(2)
-			case State_failed:
-				healthcheck.With(prometheus.Labels{"application": status.Name, "team": status.VistectureApp.Team}).Set(3)
-			case State_unknown:
-				healthcheck.With(prometheus.Labels{"application": status.Name, "team": status.VistectureApp.Team}).Set(1)
-			}
+// checkApp checks a single app within CheckTimeout, stores and publishes the result.
+// A recheck and the regular check of an app may run at the same time, the result of a check started before the one of the stored result is dropped.
+func (stm *StatusFetcher) checkApp(ignoredServices []string, app *vistectureCore.Application, resources *kubernetesResources) {
+	timeout := stm.CheckTimeout
+	if timeout <= 0 {
+		timeout = healthCheckTimeout
+	}
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	status := checkAppStatusInKubernetes(ctx, ignoredServices, app, resources)
+	cancel()
 
-		}
+	update := StatusUpdate{Time: time.Now()}
 
-		// unlock map
+	stm.mu.Lock()
+	if started.Before(stm.checkStarted[app.Name]) {
 		stm.mu.Unlock()
+		return
+	}
+	stm.checkStarted[app.Name] = started
+	stm.storeResult(status, &update)
+	stm.updateApp(status.VistectureApp.Name, &update)
+	if stm.History != nil {
+		stm.pendingChecks = append(stm.pendingChecks, toCheckResult(status, update.Time))
 	}
+	update.FetchStatus = stm.fetchStatus
+	stm.publish(update)
+	stm.scheduleRollUp()
+	stm.mu.Unlock()
+}
 
-	fetcher()
-	for range time.Tick(refreshInterval * time.Second) {
-		fetcher()
+// scheduleRollUp runs rollUp after rollUpDelay unless it is scheduled already, the caller has to hold the write lock
+func (stm *StatusFetcher) scheduleRollUp() {
+	if stm.rollUpScheduled {
+		return
 	}
+	stm.rollUpScheduled = true
+	time.AfterFunc(rollUpDelay, stm.rollUp)
 }
 
-// checkAppStatusInKubernetes iterates through k8sDeployments and controls the result channel
-func checkAppStatusInKubernetes(ignoredServices []string, app *vistectureCore.Application, k8sDeployments map[string]apps.Deployment, k8sServices map[string]v1.Service, k8sIngresses map[string][]K8sIngressInfo, k8sJobs map[string][]v1Batch.Job, k8sConfigMaps map[string]v1.ConfigMap) chan AppDeploymentInfo {
-	// result (like a futures)
-	res := make(chan AppDeploymentInfo, 1)
+// rollUp updates the impacts on all apps, the team metrics, the history and the availability metrics.
+// It takes all apps into account, so it runs once for the results of the checks within rollUpDelay instead of for every result.
+func (stm *StatusFetcher) rollUp() {
+	update := StatusUpdate{Time: time.Now()}
+
+	stm.mu.Lock()
+	stm.rollUpScheduled = false
+	records := stm.updateImpacts(&update)
+	checks := stm.pendingChecks
+	stm.pendingChecks = nil
+	results := maps.Clone(stm.apps)
+	if len(update.Changed) > 0 || len(update.Transitions) > 0 {
+		update.FetchStatus = stm.fetchStatus
+		stm.publish(update)
+	}
+	stm.mu.Unlock()
 
-	// start fetcher routing
-	go func(res chan<- AppDeploymentInfo) {
-		name := app.Name
-		config := k8sConfigMaps[name]
-		if n, ok := config.Data["k8sDeploymentName"]; ok {
-			app.Properties["k8sDeploymentName"] = n
+	stm.storeHistory(records, checks...)
+	stm.setAvailabilityMetrics(results, update.Time)
+}
+
+// storeResult saves the status of the app's own check taking the recent results into account, the caller has to hold the write lock.
+// updateApp has to be called afterwards to publish the result.
+// The results are stored by the vistecture app name, as the kubernetes name is only unique within a namespace.
+func (stm *StatusFetcher) storeResult(status AppDeploymentInfo, update *StatusUpdate) {
+	lastResults := stm.lastResults
+	status.AppStateInfo.CheckedAt = update.Time
+	key := status.VistectureApp.Name
+
+	// prepend status to list of last results
+	lastResults[key] = append([]AppDeploymentInfo{status}, lastResults[key]...)
+	if len(lastResults[key]) > 20 {
+		// limit to 20
+		lastResults[key] = lastResults[key][:20]
+	}
+
+	status.AppStateInfo.RecentCheckDurations = recentCheckDurations(lastResults[key])
+
+	countRecentUnstable := 0
+	var recentIssues []string
+	// mark as unstable if in last was a failure
+	if status.AppStateInfo.State == State_healthy {
+		for _, lastStatus := range lastResults[key] {
+			if lastStatus.AppStateInfo.State == State_failed || lastStatus.AppStateInfo.State == State_unhealthy {
+				countRecentUnstable++
+				recentIssues = append(recentIssues, lastStatus.AppStateInfo.StateReason)
+			}
 		}
+	}
 
-		var info AppDeploymentInfo
-		// Replace Name by configured Kubernetes Name
-		if n, ok := app.Properties["k8sType"]; ok && n == "job" {
-			info = checkJob(name, app, k8sJobs)
-		} else {
-			info = checkDeploymentWithHealthCheck(name, app, k8sDeployments, k8sServices, k8sIngresses)
+	if countRecentUnstable > 0 {
+		status.AppStateInfo.State = State_unstable
+		status.AppStateInfo.StateReason = fmt.Sprintf(
+			"Failed %d out of %d checks in the last %d seconds\n%s",
+			countRecentUnstable,
+			len(lastResults[key]),
+			len(lastResults[key])*refreshInterval,
+			strings.Join(recentIssues, "\n"),
+		)
+	}
+
+	stm.ownResults[key] = status
+	labels := prometheus.Labels{"application": status.Name, "team": status.VistectureApp.Team, "environment": status.Environment}
+	healthcheck.With(labels).Set(healthStatusValue(status.AppStateInfo.State))
+}
+
+// healthStatusValue is the value of the application_health_status metric: 0 ok, 1 unknown, 2 warning, 3 failed
+func healthStatusValue(state uint) float64 {
+	switch state {
+	case State_unhealthy, State_unstable, State_degraded:
+		return 2
+	case State_failed:
+		return 3
+	case State_unknown:
+		return 1
+	}
+	return 0
+}
+
+// setTeamMetrics sets the worst own state and the number of apps per state of every team
+func (stm *StatusFetcher) setTeamMetrics(results map[string]AppDeploymentInfo) {
+	worst := make(map[string]float64)
+	counts := make(map[string]map[string]int)
+	for _, status := range results {
+		team := status.VistectureApp.Team
+		if team == "" {
+			continue
 		}
 
-		if slices.Contains(ignoredServices, name) {
-			info.AppStateInfo.State = State_ignored
-			info.AppStateInfo.StateReason = "Ignored by setting override"
+		worst[team] = max(worst[team], healthStatusValue(status.AppStateInfo.OwnCheckState()))
+
+		if counts[team] == nil {
+			counts[team] = make(map[string]int)
 		}
+		counts[team][StateName(status.AppStateInfo.State)]++
+	}
 
-		res <- info
-	}(res)
+	// states without apps are removed instead of staying at their last count
+	teamApplications.DeletePartialMatch(prometheus.Labels{"environment": stm.environment})
+	for team, value := range worst {
+		teamHealthcheck.With(prometheus.Labels{"team": team, "environment": stm.environment}).Set(value)
+		for state, count := range counts[team] {
+			teamApplications.With(prometheus.Labels{"team": team, "state": state, "environment": stm.environment}).Set(float64(count))
+		}
+	}
+}
 
-	return res
+// updateApp applies the impacts of broken dependencies to the own result of the app and adds it to the update if it changed, the caller has to hold the write lock.
+// The apps impacted by it and the apps it impacts are updated by the next rollUp.
+func (stm *StatusFetcher) updateApp(key string, update *StatusUpdate) {
+	status := stm.dependencies.applyImpact(key, stm.ownResults)
+	previous, ok := stm.apps[key]
+	if ok {
+		status.AppStateInfo.Impacts = previous.AppStateInfo.Impacts
+	}
+	addChange(update, previous, ok, status)
+	stm.apps[key] = status
 }
 
-// TODO - support Cronjob also
-func checkJob(name string, app *vistectureCore.Application, k8sJobs map[string][]v1Batch.Job) AppDeploymentInfo {
-	jobs, exists := k8sJobs[name]
+// updateImpacts applies the impacts of broken dependencies to the own results and adds the changed apps to the update, the caller has to hold the write lock.
+// It returns the changed states to be stored with storeHistory.
+func (stm *StatusFetcher) updateImpacts(update *StatusUpdate) []history.Record {
+	results := stm.dependencies.applyImpacts(stm.ownResults)
+	for key, status := range results {
+		previous, ok := stm.apps[key]
+		addChange(update, previous, ok, status)
+	}
+
+	stm.apps = results
+	stm.setTeamMetrics(results)
+	return stm.recordHistory(results, update.Time)
+}
+
+// isKubernetesApp checks if the app is deployed on kubernetes
+func isKubernetesApp(app *vistectureCore.Application) bool {
+	di, ok := app.Properties["deployment"]
+	return ok && di == "kubernetes"
+}
+
+// appK8sType returns the kind of workload configured by k8sType, deployment by default
+func appK8sType(app *vistectureCore.Application) string {
+	if k8sType, ok := app.Properties["k8sType"]; ok && k8sType != "" {
+		return strings.ToLower(k8sType)
+	}
+	return K8sType_Deployment
+}
+
+// appNamespace returns the namespace of the app configured by k8sNamespace
+func appNamespace(app *vistectureCore.Application, defaultNamespace string) string {
+	if namespace, ok := app.Properties["k8sNamespace"]; ok && namespace != "" {
+		return namespace
+	}
+	return defaultNamespace
+}
+
+// addChange adds the app to the update if it is new or its state changed, and a transition if its state or own state changed
+func addChange(update *StatusUpdate, previous AppDeploymentInfo, checkedBefore bool, status AppDeploymentInfo) {
+	if !checkedBefore || stateChanged(previous, status) {
+		update.Changed = append(update.Changed, status)
+	}
+	// an impacted app changing its own state is a transition as well, e.g. to alert an app that is down itself
+	if checkedBefore && (previous.AppStateInfo.State != status.AppStateInfo.State || previous.AppStateInfo.OwnCheckState() != status.AppStateInfo.OwnCheckState()) {
+		update.Transitions = append(update.Transitions, StateTransition{
+			Previous:         previous.AppStateInfo.State,
+			PreviousOwnState: previous.AppStateInfo.OwnCheckState(),
+			App:              status,
+		})
+	}
+}
+
+// stateChanged checks if the state or its reason differs
+func stateChanged(previous, current AppDeploymentInfo) bool {
+	return previous.AppStateInfo.State != current.AppStateInfo.State ||
+		previous.AppStateInfo.OwnState != current.AppStateInfo.OwnState ||
+		previous.AppStateInfo.StateReason != current.AppStateInfo.StateReason ||
+		!slices.Equal(previous.AppStateInfo.Impacts, current.AppStateInfo.Impacts)
+}
+
+// checkAppStatusInKubernetes checks the workload or job of the app, the health checks end with the context
+func checkAppStatusInKubernetes(ctx context.Context, ignoredServices []string, app *vistectureCore.Application, resources *kubernetesResources) AppDeploymentInfo {
+	name := app.Name
+	namespace := appNamespace(app, resources.defaultNamespace)
+	config := resources.configMaps[ResourceKey(namespace, name)]
+	if n, ok := config.Data["k8sDeploymentName"]; ok {
+		// work on a copy, the app might be checked concurrently
+		appCopy := *app
+		appCopy.Properties = maps.Clone(app.Properties)
+		appCopy.Properties["k8sDeploymentName"] = n
+		app = &appCopy
+	}
+
+	var info AppDeploymentInfo
+	switch appK8sType(app) {
+	case K8sType_Job, K8sType_CronJob:
+		info = checkJob(name, namespace, app, resources, time.Now())
+	default:
+		info = checkWorkloadWithHealthCheck(ctx, name, namespace, app, resources)
+	}
+
+	info.Environment = resources.environment
+
+	if slices.Contains(ignoredServices, name) {
+		info.AppStateInfo.State = State_ignored
+		info.AppStateInfo.StateReason = "Ignored by setting override"
+	}
+
+	return info
+}
+
+// checkJob checks the last run of the CronJob or the Job named like the app
+func checkJob(name string, namespace string, app *vistectureCore.Application, resources *kubernetesResources, now time.Time) AppDeploymentInfo {
+	key := ResourceKey(namespace, name)
+	jobs, exists := resources.jobs[key]
 
 	d := AppDeploymentInfo{
 		Name:          name,
+		Namespace:     namespace,
+		K8sType:       K8sType_Job,
 		VistectureApp: *app,
 	}
 	d.AppStateInfo.HealthCheckType = HealthCheckType_Job
+	d.Events = resources.events[key]
+
+	if cronJob, ok := resources.cronJobs[key]; ok {
+		d.K8sType = K8sType_CronJob
+		return checkCronJob(d, cronJob, jobs, resources.cronJobMissedPeriods, now)
+	}
 
 	if !exists {
 		d.AppStateInfo.State = State_unknown
@@ -314,78 +1058,222 @@ func checkJob(name string, app *vistectureCore.Application, k8sJobs map[string][
 		return d
 	}
 
-	var lastJob *v1Batch.Job
+	lastJob := lastFinishedJob(jobs)
+	if lastJob == nil {
+		d.AppStateInfo.State = State_unknown
+		d.AppStateInfo.StateReason = "No completed job found"
+		return d
+	}
+
+	if jobFailed(lastJob) {
+		// one succeeded job is ok
+		d.AppStateInfo.State = State_unhealthy
+		d.AppStateInfo.StateReason = "Last job failed: " + lastJob.Name
+		return d
+	}
+
+	d.AppStateInfo.State = State_healthy
+	return d
+}
+
+// checkCronJob checks the last run of the jobs created by the CronJob and if it ran as often as scheduled
+func checkCronJob(d AppDeploymentInfo, cronJob v1Batch.CronJob, jobs []v1Batch.Job, missedPeriods int, now time.Time) AppDeploymentInfo {
+	info := &CronJobInfo{
+		Schedule:   cronJob.Spec.Schedule,
+		Suspended:  cronJob.Spec.Suspend != nil && *cronJob.Spec.Suspend,
+		ActiveJobs: len(cronJob.Status.Active),
+	}
+	if cronJob.Spec.TimeZone != nil {
+		info.TimeZone = *cronJob.Spec.TimeZone
+	}
+	if cronJob.Status.LastScheduleTime != nil {
+		info.LastScheduleTime = cronJob.Status.LastScheduleTime.Time
+	}
+	if cronJob.Status.LastSuccessfulTime != nil {
+		info.LastSuccessfulTime = cronJob.Status.LastSuccessfulTime.Time
+	}
+	// older clusters do not report the last successful time, take it from the remaining jobs
 	for _, job := range jobs {
-		if job.Status.CompletionTime == nil {
-			continue
-		}
-		if lastJob == nil {
-			lastJob = &job
+		if !jobFailed(&job) && job.Status.CompletionTime != nil && job.Status.CompletionTime.After(info.LastSuccessfulTime) {
+			info.LastSuccessfulTime = job.Status.CompletionTime.Time
 		}
+	}
+	d.CronJob = info
 
-		if lastJob.Status.CompletionTime.Before(job.Status.CompletionTime) {
-			// take newer job
-			lastJob = &job
-		}
+	for _, c := range cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers {
+		d.Images = append(d.Images, buildImageStruct(c.Image))
 	}
+	d.Labels = cronJob.Labels
 
-	if lastJob == nil {
+	schedule, err := parseCronSchedule(info.Schedule, info.TimeZone)
+	if err != nil {
 		d.AppStateInfo.State = State_unknown
-		d.AppStateInfo.StateReason = "No completed job found"
+		d.AppStateInfo.StateReason = "Invalid schedule: " + err.Error()
 		return d
 	}
+	info.NextScheduleTime = schedule.next(now)
 
-	if lastJob.Status.Succeeded == 0 && lastJob.Status.Failed > 0 {
-		// one succeeded job is ok
+	if info.Suspended {
+		d.AppStateInfo.State = State_ignored
+		d.AppStateInfo.StateReason = "CronJob is suspended"
+		return d
+	}
+
+	if lastJob := lastFinishedJob(jobs); lastJob != nil && jobFailed(lastJob) {
 		d.AppStateInfo.State = State_unhealthy
 		d.AppStateInfo.StateReason = "Last job failed: " + lastJob.Name
 		return d
 	}
 
+	// without a successful run the schedule counts from the creation of the CronJob
+	since := info.LastSuccessfulTime
+	if since.IsZero() {
+		since = cronJob.CreationTimestamp.Time
+	}
+	if deadline := schedule.nthActivation(since, missedPeriods); !deadline.IsZero() && now.After(deadline) {
+		info.MissedSchedule = true
+		d.AppStateInfo.State = State_degraded
+		if info.LastSuccessfulTime.IsZero() {
+			d.AppStateInfo.StateReason = fmt.Sprintf("Missed schedule: no successful run in %d periods of %q", missedPeriods, info.Schedule)
+		} else {
+			d.AppStateInfo.StateReason = fmt.Sprintf("Missed schedule: no successful run since %v (%d periods of %q)", info.LastSuccessfulTime.Format(time.RFC3339), missedPeriods, info.Schedule)
+		}
+		return d
+	}
+
 	d.AppStateInfo.State = State_healthy
 	return d
 }
 
-func checkDeploymentWithHealthCheck(name string, app *vistectureCore.Application, k8sDeployments map[string]apps.Deployment, k8sServices map[string]v1.Service, k8sIngresses map[string][]K8sIngressInfo) AppDeploymentInfo {
+// lastFinishedJob returns the job that finished last, successful or not
+func lastFinishedJob(jobs []v1Batch.Job) *v1Batch.Job {
+	var lastJob *v1Batch.Job
+	var lastFinished time.Time
+	for i := range jobs {
+		finished := jobFinishedAt(&jobs[i])
+		if finished.IsZero() {
+			continue
+		}
+
+		if lastJob == nil || lastFinished.Before(finished) {
+			// take newer job
+			lastJob = &jobs[i]
+			lastFinished = finished
+		}
+	}
+
+	return lastJob
+}
+
+// jobFinishedAt returns the completion time of a succeeded job or the time a job failed, the zero time for running jobs
+func jobFinishedAt(job *v1Batch.Job) time.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime.Time
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == v1Batch.JobFailed && condition.Status == v1.ConditionTrue {
+			return condition.LastTransitionTime.Time
+		}
+	}
+
+	return time.Time{}
+}
+
+// jobFailed checks if the job failed without any successful pod
+func jobFailed(job *v1Batch.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == v1Batch.JobFailed && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+
+	return job.Status.Succeeded == 0 && job.Status.Failed > 0
+}
+
+// checkWorkloadWithHealthCheck checks the deployment, stateful set or daemon set of the app and calls the healthcheck through its service
+func checkWorkloadWithHealthCheck(ctx context.Context, name string, namespace string, app *vistectureCore.Application, resources *kubernetesResources) AppDeploymentInfo {
 	// Replace Name by configured Kubernetes Name
 	if n, ok := app.Properties["k8sDeploymentName"]; ok && n != "" {
 		name = n
 	}
 
-	depl, exists := k8sDeployments[name]
-
 	d := AppDeploymentInfo{
 		Name:          name,
+		Namespace:     namespace,
+		Environment:   resources.environment,
+		K8sType:       appK8sType(app),
 		VistectureApp: *app,
 	}
 
+	var (
+		exists         bool
+		objectLabels   map[string]string
+		podSpec        v1.PodSpec
+		notReadyReason string
+	)
+
+	key := ResourceKey(namespace, name)
+	switch d.K8sType {
+	case K8sType_Deployment:
+		d.K8sDeployment, exists = resources.deployments[key]
+		objectLabels, podSpec = d.K8sDeployment.Labels, d.K8sDeployment.Spec.Template.Spec
+		d.Workload = deploymentWorkload(d.K8sDeployment)
+		if !podExists(d.K8sDeployment) {
+			notReadyReason = "No pod available"
+		}
+	case K8sType_StatefulSet:
+		d.K8sStatefulSet, exists = resources.statefulSets[key]
+		objectLabels, podSpec = d.K8sStatefulSet.Labels, d.K8sStatefulSet.Spec.Template.Spec
+		d.Workload = statefulSetWorkload(d.K8sStatefulSet)
+		if d.Workload.Ready == 0 {
+			notReadyReason = "No pod ready"
+		}
+	case K8sType_DaemonSet:
+		d.K8sDaemonSet, exists = resources.daemonSets[key]
+		objectLabels, podSpec = d.K8sDaemonSet.Labels, d.K8sDaemonSet.Spec.Template.Spec
+		d.Workload = daemonSetWorkload(d.K8sDaemonSet)
+		if d.Workload.Desired == 0 {
+			notReadyReason = "No node scheduled"
+		} else if d.Workload.Available == 0 {
+			notReadyReason = "No pod available"
+		}
+	default:
+		d.AppStateInfo.State = State_unknown
+		d.AppStateInfo.StateReason = "Unsupported k8sType " + d.K8sType
+		return d
+	}
+
+	// events might explain why the workload is missing
+	d.Events = resources.events[key]
+
 	if !exists {
+		d.Workload = WorkloadStatus{}
 		d.AppStateInfo.State = State_unknown
-		d.AppStateInfo.StateReason = "No deployment found"
+		d.AppStateInfo.StateReason = fmt.Sprintf("No %s found", d.K8sType)
 
 		return d
 	}
 
 	// add ingresses found for kubernetes Name
-	d.Ingress = k8sIngresses[name]
+	d.Ingress = resources.ingresses[ResourceKey(namespace, name)]
+	d.Pods = podInfos(resources.pods[key])
 
-	d.K8sDeployment = depl
-
-	for _, c := range depl.Spec.Template.Spec.Containers {
+	for _, c := range podSpec.Containers {
 		d.Images = append(d.Images, buildImageStruct(c.Image))
 	}
 
 	d.Labels = make(map[string]string)
-	for k, e := range depl.Labels {
+	for k, e := range objectLabels {
 		if k == "helm.sh/version" {
 			d.Labels["helm"] = e
 		}
 		d.Labels[k] = e
 	}
 
-	if !podExists(depl) {
+	if notReadyReason != "" {
 		d.AppStateInfo.State = State_failed
-		d.AppStateInfo.StateReason = "No pod available"
+		d.AppStateInfo.StateReason = notReadyReason
 		return d
 	}
 
@@ -394,13 +1282,13 @@ func checkDeploymentWithHealthCheck(name string, app *vistectureCore.Application
 	if h, ok := app.Properties["k8sHealthCheckServiceName"]; ok {
 		k8sHealthCheckServiceName = h
 		// Add ingresses that might exists for seperate k8sHealthCheckServiceName
-		d.Ingress = append(d.Ingress, k8sIngresses[k8sHealthCheckServiceName]...)
+		d.Ingress = append(d.Ingress, resources.ingresses[ResourceKey(namespace, k8sHealthCheckServiceName)]...)
 	}
-	service, serviceExists := k8sServices[k8sHealthCheckServiceName]
+	service, serviceExists := resources.services[ResourceKey(namespace, k8sHealthCheckServiceName)]
 
 	if !serviceExists {
 		d.AppStateInfo.State = State_failed
-		d.AppStateInfo.StateReason = "Deployment has no service for healthcheck that matches the config / " + k8sHealthCheckServiceName
+		d.AppStateInfo.StateReason = fmt.Sprintf("%s has no service for healthcheck that matches the config / %s", k8sKinds[d.K8sType], k8sHealthCheckServiceName)
 		return d
 	}
 	if len(service.Spec.Ports) < 1 {
@@ -414,33 +1302,72 @@ func checkDeploymentWithHealthCheck(name string, app *vistectureCore.Application
 	}
 
 	// Add a link to apiDocPath if possible:
-	if len(k8sIngresses[name]) > 0 {
+	if ingresses := resources.ingresses[ResourceKey(namespace, name)]; len(ingresses) > 0 {
 		if apiDocPath, ok := app.Properties["apiDocPath"]; ok {
-			d.ApiDocumentationUrl = fmt.Sprintf("https://%v/%v", k8sIngresses[name][0].Host, apiDocPath)
+			d.ApiDocumentationUrl = fmt.Sprintf("https://%v/%v", ingresses[0].Host, apiDocPath)
 		}
 	}
 
+	healthCheck := resources.healthCheck(app)
+	if healthCheck.err != nil {
+		d.AppStateInfo.State = State_failed
+		d.AppStateInfo.StateReason = healthCheck.err.Error()
+		return d
+	}
+	checker := healthCheck.checker
+
 	foundHealthcheckPort := findHealthcheckPort(app, service)
 
-	domain := fmt.Sprintf("%s:%d", k8sHealthCheckServiceName, foundHealthcheckPort)
-	healthStatusOfService, reason, healthcheckType := checkHealth(d, "http://"+domain, app.Properties["healthCheckPath"])
-	d.AppStateInfo.HealthCheckType = healthcheckType
+	// services of other namespaces are only resolvable with their namespace
+	host := k8sHealthCheckServiceName
+	if namespace != resources.defaultNamespace {
+		host = k8sHealthCheckServiceName + "." + namespace
+	}
+	domain := fmt.Sprintf("%s:%d", host, foundHealthcheckPort)
+	target := HealthCheckTarget{Address: domain, Scheme: "http", Path: app.Properties["healthCheckPath"]}
+
+	// services of other clusters are called through their API server, which only proxies HTTP
+	if proxy := resources.serviceProxy; proxy != nil {
+		httpChecker, ok := checker.(*HTTPHealthChecker)
+		if !ok {
+			d.AppStateInfo.State = State_unknown
+			d.AppStateInfo.StateReason = fmt.Sprintf("healthCheckType %v is not supported for the services of another cluster", app.Properties["healthCheckType"])
+			return d
+		}
+
+		proxyChecker := *httpChecker
+		proxyChecker.Client = proxy.Client
+		checker = &proxyChecker
+		target = proxy.Target(namespace, k8sHealthCheckServiceName, foundHealthcheckPort, target.Path)
+	}
+
+	result := checker.Check(ctx, target)
+	d.AppStateInfo.HealthCheckType = result.Type
+	d.AppStateInfo.CheckDuration = result.Latency
+	observeCheckDuration(d, healthCheckPath_Internal, result.Latency)
+	d.HealthCheckServices = result.Services
+	setDependencyMetrics(d, result.Services)
 
-	if !healthStatusOfService {
+	if !result.Healthy {
 		d.AppStateInfo.State = State_unhealthy
-		d.AppStateInfo.StateReason = "Service Unhealthy: " + reason
+		d.AppStateInfo.StateReason = fmt.Sprintf("Service Unhealthy (%s): %s", result.Cause, result.Reason)
+		// the pods might tell why
+		if reasons := degradedReasons(d.Workload, d.Pods, time.Now()); len(reasons) > 0 {
+			d.AppStateInfo.StateReason += "\n" + strings.Join(reasons, "\n")
+		}
 		return d
 	}
 
 	// In case the application need to be checked from outside, do the check and let it fail if unhealthy/misconfigured
 	if _, ok := app.Properties["k8sHealthCheckThroughIngress"]; ok {
+		serviceIngresses := resources.ingresses[ResourceKey(namespace, k8sHealthCheckServiceName)]
 		// Try to do the healthcheck from ingress
-		if len(k8sIngresses[k8sHealthCheckServiceName]) > 0 {
-			d.AppStateInfo.HealthyAlsoFromIngress = checkPublicHealth(k8sIngresses[k8sHealthCheckServiceName], app.Properties["healthCheckPath"])
+		if len(serviceIngresses) > 0 {
+			d.AppStateInfo.HealthyAlsoFromIngress, d.AppStateInfo.IngressCheckDuration = checkPublicHealth(ctx, d, serviceIngresses, app.Properties["healthCheckPath"], healthCheck.publicChecker)
 		}
 
 		if !d.AppStateInfo.HealthyAlsoFromIngress {
-			if len(k8sIngresses[k8sHealthCheckServiceName]) == 0 {
+			if len(serviceIngresses) == 0 {
 				d.AppStateInfo.State = State_failed
 				d.AppStateInfo.StateReason = "No Ingress for service " + k8sHealthCheckServiceName
 			} else {
@@ -451,89 +1378,106 @@ func checkDeploymentWithHealthCheck(name string, app *vistectureCore.Application
 		}
 	}
 
+	// the app works, but some pods need attention or it is slow
+	reasons := degradedReasons(d.Workload, d.Pods, time.Now())
+	if maxLatency := healthCheck.maxLatency; maxLatency > 0 && result.Latency > maxLatency {
+		reasons = append(reasons, fmt.Sprintf("Healthcheck took %v, more than %v", result.Latency.Round(time.Millisecond), maxLatency))
+	}
+	if len(reasons) > 0 {
+		d.AppStateInfo.State = State_degraded
+		d.AppStateInfo.StateReason = strings.Join(reasons, "\n")
+		return d
+	}
+
 	d.AppStateInfo.State = State_healthy
 	return d
 }
 
+// healthCheck returns the health check of the app built by the StatusFetcher, or builds it for an app unknown to it
+func (r *kubernetesResources) healthCheck(app *vistectureCore.Application) *appHealthCheck {
+	if check, ok := r.healthChecks[app.Name]; ok {
+		return check
+	}
+	return newAppHealthCheck(app)
+}
+
 func podExists(deployment apps.Deployment) bool {
 	return deployment.Status.AvailableReplicas != 0
 }
 
-// checkPublicHealth calls the healthcheck via public ingress
-func checkPublicHealth(ingresses []K8sIngressInfo, healtcheckPath string) bool {
-	var reason string
-	var checktype string
-	var ok bool
-	for _, ing := range ingresses {
-		// At least one ingress should succeed
-		ok, reason, checktype = checkHealth(AppDeploymentInfo{}, "https://"+ing.Host, healtcheckPath)
-		if ok {
-			return true
-		}
+// deploymentWorkload returns the replica status of a deployment
+func deploymentWorkload(deployment apps.Deployment) WorkloadStatus {
+	w := WorkloadStatus{
+		Desired:            desiredReplicas(deployment.Spec.Replicas),
+		Current:            deployment.Status.Replicas,
+		Ready:              deployment.Status.ReadyReplicas,
+		Available:          deployment.Status.AvailableReplicas,
+		ObservedGeneration: deployment.Status.ObservedGeneration,
 	}
-	log.Printf("checkPublicHealth failed Reason:%v / Via:%v", reason, checktype)
-	return false
+	for _, c := range deployment.Status.Conditions {
+		w.Conditions = append(w.Conditions, WorkloadCondition{Type: string(c.Type), Status: string(c.Status), Message: c.Message})
+	}
+	return w
 }
 
-func checkHealth(status AppDeploymentInfo, checkBaseUrl string, healtcheckPath string) (bool, string, string) {
-	checkUrl := checkBaseUrl + healtcheckPath
-
-	req, reqErr := http.NewRequest("GET", checkUrl, nil)
-	if reqErr != nil {
-		return false, reqErr.Error(), HealthCheckType_NotCheckedYet
+// statefulSetWorkload returns the replica status of a stateful set
+func statefulSetWorkload(statefulSet apps.StatefulSet) WorkloadStatus {
+	w := WorkloadStatus{
+		Desired:            desiredReplicas(statefulSet.Spec.Replicas),
+		Current:            statefulSet.Status.Replicas,
+		Ready:              statefulSet.Status.ReadyReplicas,
+		Available:          statefulSet.Status.AvailableReplicas,
+		ObservedGeneration: statefulSet.Status.ObservedGeneration,
 	}
-
-	req.Header.Set("User-Agent", healthCheckUserAgent)
-	r, httpErr := httpClient.Do(req)
-
-	if httpErr != nil {
-		return false, httpErr.Error(), HealthCheckType_NotCheckedYet
+	for _, c := range statefulSet.Status.Conditions {
+		w.Conditions = append(w.Conditions, WorkloadCondition{Type: string(c.Type), Status: string(c.Status), Message: c.Message})
 	}
+	return w
+}
 
-	statusCode := r.StatusCode
-
-	if healtcheckPath != "" {
-		// Parse healthcheck
-		jsonMap := &HealthCheckResponse{
-			Services: []HealthCheckService{},
-		}
-
-		responseBody, bodyErr := io.ReadAll(r.Body)
-		if bodyErr != nil {
-			return false, "Could not read from HealthcheckPath", HealthCheckType_HealthCheck
-		}
-		jsonError := json.Unmarshal(responseBody, jsonMap)
-		// Check if Response is valid
-		if jsonError != nil {
-			return false, fmt.Sprintf("HealthcheckPath Format Error from %s", checkUrl), HealthCheckType_HealthCheck
-		}
-
-		statusText := fmt.Sprintf("Status %v for %v ", statusCode, checkUrl)
-		finalStatus := true
-
-		for _, service := range jsonMap.Services {
-			s := float64(0)
-			if !service.Alive {
-				statusText += fmt.Sprintf("%v (%v) \n", service.Name, service.Details)
-				s = 1
-				finalStatus = false
-			}
-
-			if status.Name != "" {
-				healthcheckDependencies.With(prometheus.Labels{"application": status.Name, "dependency": service.Name, "team": status.VistectureApp.Team}).Set(s)
-			}
-		}
-
-		return finalStatus, statusText, HealthCheckType_HealthCheck
+// desiredReplicas returns the replicas of the spec, kubernetes defaults them to 1
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
 	}
+	return *replicas
+}
 
-	// Fallback if no healthcheck is configured
-	if statusCode > 500 {
-		return false, fmt.Sprintf("Fallbackcheck returns error status %v ", statusCode), HealthCheckType_SimpleCheck
+// daemonSetWorkload returns the status of a daemon set, the replicas are the pods scheduled on the nodes
+func daemonSetWorkload(daemonSet apps.DaemonSet) WorkloadStatus {
+	w := WorkloadStatus{
+		Desired:            daemonSet.Status.DesiredNumberScheduled,
+		Current:            daemonSet.Status.CurrentNumberScheduled,
+		Ready:              daemonSet.Status.NumberReady,
+		Available:          daemonSet.Status.NumberAvailable,
+		ObservedGeneration: daemonSet.Status.ObservedGeneration,
 	}
+	if daemonSet.Status.CurrentNumberScheduled < daemonSet.Status.DesiredNumberScheduled {
+		w.Conditions = append(w.Conditions, WorkloadCondition{
+			Type:    "Scheduled",
+			Status:  string(v1.ConditionFalse),
+			Message: fmt.Sprintf("%d of %d nodes scheduled", daemonSet.Status.CurrentNumberScheduled, daemonSet.Status.DesiredNumberScheduled),
+		})
+	}
+	for _, c := range daemonSet.Status.Conditions {
+		w.Conditions = append(w.Conditions, WorkloadCondition{Type: string(c.Type), Status: string(c.Status), Message: c.Message})
+	}
+	return w
+}
 
-	return true, "", HealthCheckType_SimpleCheck
-
+// checkPublicHealth calls the healthcheck via public ingress and returns the duration of the last check
+func checkPublicHealth(ctx context.Context, status AppDeploymentInfo, ingresses []K8sIngressInfo, healtcheckPath string, checker *HTTPHealthChecker) (bool, time.Duration) {
+	var result HealthCheckResult
+	for _, ing := range ingresses {
+		// At least one ingress should succeed
+		result = checker.Check(ctx, HealthCheckTarget{Address: ing.Host, Scheme: "https", Path: healtcheckPath})
+		observeCheckDuration(status, healthCheckPath_Ingress, result.Latency)
+		if result.Healthy {
+			return true, result.Latency
+		}
+	}
+	log.Printf("checkPublicHealth failed Reason:%v / Via:%v", result.Reason, result.Type)
+	return false, result.Latency
 }
 
 func findHealthcheckPort(app *vistectureCore.Application, service v1.Service) int32 {
@@ -1,57 +1,21 @@
 package kube
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func TestCheckHealth_AllHealthy(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte("{\"services\": []}"))
-
-	}))
-	defer server.Close()
-
-	healthStatusOfService, reason, _ := checkHealth(AppDeploymentInfo{}, server.URL, "/")
-	if !healthStatusOfService {
-		t.Errorf("healthStatusOfService should be true %v", reason)
-	}
-}
-
-func TestCheckHealth_UnhealthyService(t *testing.T) {
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = w.Write([]byte("{\"services\": [{\"name\": \"dummy\", \"alive\": false, \"details\": \"dummy\"}]}"))
-	}))
-	defer server.Close()
-
-	healthStatusOfService, _, _ := checkHealth(AppDeploymentInfo{}, server.URL, "/nonexistingpath")
-	if healthStatusOfService {
-		t.Errorf("healthStatusOfService should be false")
-	}
-}
-
-func TestCheckHealth_UserAgentIsSet(t *testing.T) {
-	expectedUA := "VistectureDashboard"
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ua := r.Header.Get("User-Agent")
-		if ua != expectedUA {
-			w.WriteHeader(500)
-			t.Errorf("expected user-agent to be '%s' but was '%s'", expectedUA, ua)
-		} else {
-			w.WriteHeader(200)
-		}
-	}))
-	defer server.Close()
-
-	healthStatusOfService, _, _ := checkHealth(AppDeploymentInfo{}, server.URL, "/")
-	if healthStatusOfService {
-		t.Errorf("user-agent assertion failed")
-	}
-}
-
 func TestPodExists(t *testing.T) {
 	testCases := []struct {
 		currentReplicas int32
@@ -83,3 +47,467 @@ func TestPodExists(t *testing.T) {
 		}
 	}
 }
+
+func TestStatusFetcher_FetchFailedKeepsResults(t *testing.T) {
+	stm := NewStatusFetcher("default", nil, NewDemoService(0))
+	stm.apps["flamingo"] = AppDeploymentInfo{Name: "flamingo", AppStateInfo: AppStateInfo{State: State_healthy}}
+	stm.fetchStatus = FetchStatus{LastSuccess: time.Now().Add(-time.Minute)}
+
+	stm.fetchFailed(errors.New("connection refused"))
+	stm.fetchFailed(errors.New("connection refused"))
+
+	fetchStatus := stm.GetFetchStatus()
+	if !fetchStatus.Stale() {
+		t.Error("expected the result to be stale")
+	}
+	if fetchStatus.ConsecutiveFailures != 2 {
+		t.Errorf("expected 2 consecutive failures, got %d", fetchStatus.ConsecutiveFailures)
+	}
+	if fetchStatus.Age() < time.Minute {
+		t.Errorf("expected age of at least a minute, got %v", fetchStatus.Age())
+	}
+	if _, ok := stm.GetCurrentResult()["flamingo"]; !ok {
+		t.Error("expected the last result to be kept")
+	}
+}
+
+func TestCheckAppStatusInKubernetes_Namespaces(t *testing.T) {
+	resources := &kubernetesResources{
+		defaultNamespace: "frontend",
+		deployments: map[string]apps.Deployment{
+			"frontend/api": {Status: apps.DeploymentStatus{AvailableReplicas: 0}},
+			"backend/api":  {Status: apps.DeploymentStatus{AvailableReplicas: 1}},
+		},
+	}
+
+	frontendApi := &vistectureCore.Application{Name: "api", Properties: map[string]string{"deployment": "kubernetes"}}
+	backendApi := &vistectureCore.Application{Name: "api", Properties: map[string]string{"deployment": "kubernetes", "k8sNamespace": "backend"}}
+
+	status := checkAppStatusInKubernetes(t.Context(), nil, frontendApi, resources)
+	if status.Namespace != "frontend" || status.AppStateInfo.StateReason != "No pod available" {
+		t.Errorf("expected frontend/api without pods, got %v: %v", status.Namespace, status.AppStateInfo.StateReason)
+	}
+
+	status = checkAppStatusInKubernetes(t.Context(), nil, backendApi, resources)
+	if status.Namespace != "backend" || !strings.HasPrefix(status.AppStateInfo.StateReason, "Deployment has no service") {
+		t.Errorf("expected backend/api without service, got %v: %v", status.Namespace, status.AppStateInfo.StateReason)
+	}
+}
+
+func TestCheckAppStatusInKubernetes_Workloads(t *testing.T) {
+	resources := &kubernetesResources{
+		defaultNamespace: "default",
+		statefulSets: map[string]apps.StatefulSet{
+			"default/elasticsearch": {Status: apps.StatefulSetStatus{Replicas: 3, ReadyReplicas: 0}},
+		},
+		daemonSets: map[string]apps.DaemonSet{
+			"default/node-exporter": {Status: apps.DaemonSetStatus{DesiredNumberScheduled: 0}},
+			"default/fluentd":       {Status: apps.DaemonSetStatus{DesiredNumberScheduled: 2, NumberAvailable: 2}},
+		},
+	}
+
+	testCases := []struct {
+		name, k8sType, expectedReason string
+	}{
+		{name: "elasticsearch", k8sType: "statefulset", expectedReason: "No pod ready"},
+		{name: "rabbitmq", k8sType: "statefulset", expectedReason: "No statefulset found"},
+		{name: "node-exporter", k8sType: "daemonset", expectedReason: "No node scheduled"},
+		{name: "fluentd", k8sType: "DaemonSet", expectedReason: "DaemonSet has no service for healthcheck that matches the config / fluentd"},
+		{name: "api", k8sType: "replicaset", expectedReason: "Unsupported k8sType replicaset"},
+	}
+
+	for _, tc := range testCases {
+		app := &vistectureCore.Application{Name: tc.name, Properties: map[string]string{"deployment": "kubernetes", "k8sType": tc.k8sType}}
+		status := checkAppStatusInKubernetes(t.Context(), nil, app, resources)
+		if status.AppStateInfo.StateReason != tc.expectedReason {
+			t.Errorf("%s: expected reason %q, got %q", tc.name, tc.expectedReason, status.AppStateInfo.StateReason)
+		}
+	}
+}
+
+func TestCheckJob_CronJob(t *testing.T) {
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	at := func(d time.Duration) *metav1.Time {
+		t := metav1.NewTime(now.Add(d))
+		return &t
+	}
+	suspend := true
+	owner := []metav1.OwnerReference{{Kind: "CronJob", Name: "export"}}
+
+	testCases := []struct {
+		name           string
+		cronJob        batch.CronJob
+		jobs           []*batch.Job
+		expectedState  uint
+		expectedReason string
+	}{
+		{
+			name: "healthy",
+			cronJob: batch.CronJob{
+				Spec:   batch.CronJobSpec{Schedule: "0 * * * *"},
+				Status: batch.CronJobStatus{LastSuccessfulTime: at(-30 * time.Minute)},
+			},
+			expectedState: State_healthy,
+		},
+		{
+			name: "missed",
+			cronJob: batch.CronJob{
+				Spec:   batch.CronJobSpec{Schedule: "0 * * * *"},
+				Status: batch.CronJobStatus{LastSuccessfulTime: at(-150 * time.Minute)},
+			},
+			expectedState:  State_degraded,
+			expectedReason: "Missed schedule: no successful run since 2024-05-15T08:00:00Z (2 periods of \"0 * * * *\")",
+		},
+		{
+			name: "success from jobs",
+			cronJob: batch.CronJob{
+				Spec: batch.CronJobSpec{Schedule: "0 * * * *"},
+			},
+			jobs: []*batch.Job{
+				{ObjectMeta: metav1.ObjectMeta{Name: "export-1", OwnerReferences: owner}, Status: batch.JobStatus{Succeeded: 1, CompletionTime: at(-20 * time.Minute)}},
+			},
+			expectedState: State_healthy,
+		},
+		{
+			name: "last job failed",
+			cronJob: batch.CronJob{
+				Spec:   batch.CronJobSpec{Schedule: "0 * * * *"},
+				Status: batch.CronJobStatus{LastSuccessfulTime: at(-90 * time.Minute)},
+			},
+			jobs: []*batch.Job{
+				{ObjectMeta: metav1.ObjectMeta{Name: "export-2", OwnerReferences: owner}, Status: batch.JobStatus{
+					Failed:     1,
+					Conditions: []batch.JobCondition{{Type: batch.JobFailed, Status: "True", LastTransitionTime: *at(-25 * time.Minute)}},
+				}},
+			},
+			expectedState:  State_unhealthy,
+			expectedReason: "Last job failed: export-2",
+		},
+		{
+			name: "suspended",
+			cronJob: batch.CronJob{
+				Spec: batch.CronJobSpec{Schedule: "0 * * * *", Suspend: &suspend},
+			},
+			expectedState:  State_ignored,
+			expectedReason: "CronJob is suspended",
+		},
+	}
+
+	app := &vistectureCore.Application{Name: "export", Properties: map[string]string{"deployment": "kubernetes", "k8sType": "cronjob"}}
+	for _, tc := range testCases {
+		for _, job := range tc.jobs {
+			job.Namespace = "default"
+		}
+		resources := &kubernetesResources{
+			cronJobs:             map[string]batch.CronJob{"default/export": tc.cronJob},
+			jobs:                 groupJobsByApp(tc.jobs),
+			cronJobMissedPeriods: 2,
+		}
+
+		status := checkJob("export", "default", app, resources, now)
+		if status.AppStateInfo.State != tc.expectedState || status.AppStateInfo.StateReason != tc.expectedReason {
+			t.Errorf("%s: expected %v %q, got %v %q", tc.name, tc.expectedState, tc.expectedReason, status.AppStateInfo.State, status.AppStateInfo.StateReason)
+		}
+		if status.CronJob == nil || status.K8sType != K8sType_CronJob {
+			t.Errorf("%s: expected cron job info", tc.name)
+		}
+	}
+}
+
+func TestStatusFetcher_UpdateImpactsTransitions(t *testing.T) {
+	stm := NewStatusFetcher("prod", nil, NewDemoService(0))
+	result := func(state uint, reason string) AppDeploymentInfo {
+		return AppDeploymentInfo{VistectureApp: vistectureCore.Application{Name: "flamingo"}, AppStateInfo: AppStateInfo{State: state, StateReason: reason}}
+	}
+
+	update := StatusUpdate{}
+	stm.ownResults["flamingo"] = result(State_healthy, "")
+	stm.updateImpacts(&update)
+	if len(update.Changed) != 1 || len(update.Transitions) != 0 {
+		t.Errorf("expected the first result to be changed without transition, got %+v", update)
+	}
+
+	update = StatusUpdate{}
+	stm.ownResults["flamingo"] = result(State_healthy, "other reason")
+	stm.updateImpacts(&update)
+	if len(update.Changed) != 1 || len(update.Transitions) != 0 {
+		t.Errorf("expected a changed reason without transition, got %+v", update)
+	}
+
+	update = StatusUpdate{}
+	stm.ownResults["flamingo"] = result(State_failed, "No pod available")
+	stm.updateImpacts(&update)
+	if len(update.Transitions) != 1 || update.Transitions[0].Previous != State_healthy || update.Transitions[0].App.AppStateInfo.State != State_failed {
+		t.Errorf("expected a transition from healthy to failed, got %+v", update.Transitions)
+	}
+}
+
+func TestStatusFetcher_UpdateAppLeavesImpactsToRollUp(t *testing.T) {
+	definedApps := []*vistectureCore.Application{
+		{Name: "flamingo", Dependencies: []vistectureCore.DependencyReference{{Reference: "akeneo"}}},
+		{Name: "akeneo"},
+	}
+	stm := NewStatusFetcher("prod", definedApps, NewDemoService(0))
+	result := func(name string, state uint) AppDeploymentInfo {
+		return AppDeploymentInfo{VistectureApp: vistectureCore.Application{Name: name}, AppStateInfo: AppStateInfo{State: state}}
+	}
+
+	stm.ownResults["akeneo"] = result("akeneo", State_healthy)
+	stm.ownResults["flamingo"] = result("flamingo", State_healthy)
+	stm.updateImpacts(&StatusUpdate{})
+
+	update := StatusUpdate{}
+	stm.ownResults["akeneo"] = result("akeneo", State_failed)
+	stm.updateApp("akeneo", &update)
+	if len(update.Changed) != 1 || update.Changed[0].VistectureApp.Name != "akeneo" || len(update.Transitions) != 1 {
+		t.Errorf("expected only the checked app to be updated, got %+v", update)
+	}
+	if stm.apps["flamingo"].AppStateInfo.State != State_healthy {
+		t.Errorf("expected the impacted app to wait for the roll-up, got %+v", stm.apps["flamingo"])
+	}
+
+	update = StatusUpdate{}
+	stm.ownResults["flamingo"] = result("flamingo", State_healthy)
+	stm.updateApp("flamingo", &update)
+	if stm.apps["flamingo"].AppStateInfo.State != State_impacted || len(update.Transitions) != 1 {
+		t.Errorf("expected the checked app to be impacted by its broken dependency, got %+v", update)
+	}
+
+	update = StatusUpdate{}
+	stm.updateImpacts(&update)
+	if len(update.Changed) != 1 || update.Changed[0].VistectureApp.Name != "akeneo" || len(update.Transitions) != 0 {
+		t.Errorf("expected the roll-up to only add the impacts of the root cause, got %+v", update)
+	}
+	if impacts := stm.apps["akeneo"].AppStateInfo.Impacts; len(impacts) != 1 || impacts[0] != "flamingo" {
+		t.Errorf("expected the root cause to list the impacted app, got %v", impacts)
+	}
+}
+
+func TestStatusFetcher_UpdateImpactsOwnStateTransitions(t *testing.T) {
+	definedApps := []*vistectureCore.Application{
+		{Name: "flamingo", Dependencies: []vistectureCore.DependencyReference{{Reference: "akeneo"}}},
+		{Name: "akeneo"},
+	}
+	stm := NewStatusFetcher("prod", definedApps, NewDemoService(0))
+	result := func(name string, state uint) AppDeploymentInfo {
+		return AppDeploymentInfo{VistectureApp: vistectureCore.Application{Name: name}, AppStateInfo: AppStateInfo{State: state}}
+	}
+
+	stm.ownResults["akeneo"] = result("akeneo", State_failed)
+	stm.ownResults["flamingo"] = result("flamingo", State_healthy)
+	stm.updateImpacts(&StatusUpdate{})
+
+	update := StatusUpdate{}
+	stm.ownResults["flamingo"] = result("flamingo", State_degraded)
+	stm.updateImpacts(&update)
+	if len(update.Transitions) != 1 {
+		t.Fatalf("expected a transition of the own state of the impacted app, got %+v", update.Transitions)
+	}
+	transition := update.Transitions[0]
+	if transition.Previous != State_impacted || transition.PreviousOwnState != State_healthy ||
+		transition.App.AppStateInfo.State != State_impacted || transition.App.AppStateInfo.OwnState != State_degraded {
+		t.Errorf("unexpected transition %+v", transition)
+	}
+}
+
+func TestStatusFetcher_CheckAppsPublishesPerApp(t *testing.T) {
+	definedApps := []*vistectureCore.Application{
+		{Name: "api", Properties: map[string]string{"deployment": "kubernetes"}},
+		{Name: "worker", Properties: map[string]string{"deployment": "kubernetes"}},
+		{Name: "search", Properties: map[string]string{"deployment": "kubernetes"}},
+		{Name: "legacy", Properties: map[string]string{"deployment": "vm"}},
+	}
+	resources := &kubernetesResources{
+		defaultNamespace: "default",
+		deployments: map[string]apps.Deployment{
+			"default/api":    {},
+			"default/worker": {},
+		},
+	}
+
+	stm := NewStatusFetcher("default", definedApps, nil)
+	stm.CheckConcurrency = 2
+	stm.CheckJitter = time.Millisecond
+	updates, unsubscribe := stm.Subscribe()
+	defer unsubscribe()
+	var listened []StatusUpdate
+	stm.OnUpdate(func(update StatusUpdate) { listened = append(listened, update) })
+
+	stm.checkApps(nil, resources)
+
+	if len(listened) != 4 {
+		t.Errorf("expected the listener to receive every update, got %d", len(listened))
+	}
+
+	for range 3 {
+		update := <-updates
+		if len(update.Changed) != 1 || update.Cycle {
+			t.Errorf("expected one app per update, got %+v", update)
+		}
+	}
+	if update := <-updates; !update.Cycle || len(update.Changed) != 0 {
+		t.Errorf("expected the end of the cycle after the apps, got %+v", update)
+	}
+	select {
+	case update := <-updates:
+		t.Errorf("unexpected update %+v", update)
+	default:
+	}
+
+	if results := stm.GetCurrentResult(); len(results) != 3 || results["search"].AppStateInfo.StateReason != "No deployment found" {
+		t.Errorf("expected the results of the kubernetes apps, got %+v", results)
+	}
+}
+
+func TestStatusFetcher_SlowSubscriberClosed(t *testing.T) {
+	stm := NewStatusFetcher("default", nil, nil)
+	slow, unsubscribeSlow := stm.Subscribe()
+	defer unsubscribeSlow()
+	updates, unsubscribe := stm.Subscribe()
+	defer unsubscribe()
+
+	stm.mu.Lock()
+	for i := range 101 {
+		stm.publish(StatusUpdate{Time: time.Unix(int64(i), 0)})
+		if i < 100 {
+			<-updates
+		}
+	}
+	stm.mu.Unlock()
+
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != 100 {
+		t.Errorf("expected the buffered updates before the channel is closed, got %d", received)
+	}
+	if update := <-updates; update.Time.Unix() != 100 {
+		t.Errorf("expected the other subscriber to keep receiving updates, got %+v", update)
+	}
+}
+
+func TestStatusFetcher_RecheckDeploymentDebounced(t *testing.T) {
+	recheckDelay = 10 * time.Millisecond
+	defer func() { recheckDelay = 2 * time.Second }()
+
+	definedApps := []*vistectureCore.Application{
+		{Name: "api", Properties: map[string]string{"deployment": "kubernetes"}},
+		{Name: "worker", Properties: map[string]string{"deployment": "kubernetes"}},
+	}
+	stm := NewStatusFetcher("default", definedApps, &podsService{DemoService: NewDemoService(0), pods: map[string][]v1.Pod{
+		"default/api": {{ObjectMeta: metav1.ObjectMeta{Name: "api-7d4b9c-x2k8p"}}},
+	}})
+	stm.resources = &kubernetesResources{
+		defaultNamespace: "default",
+		deployments: map[string]apps.Deployment{
+			"default/api":    {Status: apps.DeploymentStatus{AvailableReplicas: 1}},
+			"default/worker": {Status: apps.DeploymentStatus{AvailableReplicas: 1}},
+		},
+	}
+	updates, unsubscribe := stm.Subscribe()
+	defer unsubscribe()
+
+	scaledDown := apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}}
+	for range 3 {
+		stm.recheckDeployment(scaledDown)
+	}
+
+	select {
+	case update := <-updates:
+		if len(update.Changed) != 1 || update.Changed[0].Name != "api" || update.Changed[0].AppStateInfo.StateReason != "No pod available" {
+			t.Errorf("expected api to be rechecked with the changed deployment, got %+v", update.Changed)
+		}
+		if len(update.Changed) == 1 && len(update.Changed[0].Pods) != 1 {
+			t.Errorf("expected the pods to be read again for the recheck, got %+v", update.Changed[0].Pods)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a recheck of api")
+	}
+
+	select {
+	case update := <-updates:
+		t.Errorf("expected a single recheck, got %+v", update)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if stm.resources.deployments["default/api"].Status.AvailableReplicas != 1 || len(stm.resources.pods) != 0 {
+		t.Error("expected the resources of the last fetch to be unchanged")
+	}
+}
+
+// podsService returns the pods instead of the fake ones
+type podsService struct {
+	*DemoService
+	pods map[string][]v1.Pod
+}
+
+func (s *podsService) GetPodsByWorkload() (map[string][]v1.Pod, error) {
+	return s.pods, nil
+}
+
+func TestStatusFetcher_CheckAppDropsOutdatedResult(t *testing.T) {
+	app := &vistectureCore.Application{Name: "api", Properties: map[string]string{"deployment": "kubernetes"}}
+	stm := NewStatusFetcher("default", []*vistectureCore.Application{app}, nil)
+	resources := &kubernetesResources{defaultNamespace: "default"}
+
+	stm.checkApp(nil, app, resources)
+	if _, ok := stm.GetCurrentResult()["api"]; !ok {
+		t.Fatal("expected the result of api")
+	}
+
+	// a recheck started later finished first
+	stm.checkStarted["api"] = time.Now().Add(time.Hour)
+	resources.deployments = map[string]apps.Deployment{"default/api": {Status: apps.DeploymentStatus{AvailableReplicas: 1}}}
+	stm.checkApp(nil, app, resources)
+	if reason := stm.GetCurrentResult()["api"].AppStateInfo.StateReason; reason != "No deployment found" {
+		t.Errorf("expected the result of the earlier check to be dropped, got %q", reason)
+	}
+}
+
+func TestCheckAppStatusInKubernetes_ServiceProxy(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		_, _ = w.Write([]byte(`{"services": []}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	resources := &kubernetesResources{
+		defaultNamespace: "shop",
+		deployments: map[string]apps.Deployment{
+			"shop/flamingo": {Status: apps.DeploymentStatus{AvailableReplicas: 1}},
+			"shop/akeneo":   {Status: apps.DeploymentStatus{AvailableReplicas: 1}},
+			"shop/postgres": {Status: apps.DeploymentStatus{AvailableReplicas: 1}},
+		},
+		services: map[string]v1.Service{
+			"shop/flamingo": {Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 8080}}}},
+			"shop/akeneo":   {Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 80}}}},
+			"shop/postgres": {Spec: v1.ServiceSpec{Ports: []v1.ServicePort{{Port: 5432}}}},
+		},
+		serviceProxy: &ServiceProxy{Client: server.Client(), Server: serverURL},
+	}
+
+	flamingo := &vistectureCore.Application{Name: "flamingo", Properties: map[string]string{"deployment": "kubernetes", "healthCheckPath": "/health"}}
+	status := checkAppStatusInKubernetes(t.Context(), nil, flamingo, resources)
+	if status.AppStateInfo.State != State_healthy {
+		t.Errorf("expected flamingo to be healthy, got %v: %v", status.AppStateInfo.State, status.AppStateInfo.StateReason)
+	}
+
+	akeneo := &vistectureCore.Application{Name: "akeneo", Properties: map[string]string{"deployment": "kubernetes"}}
+	status = checkAppStatusInKubernetes(t.Context(), nil, akeneo, resources)
+	if status.AppStateInfo.State != State_healthy {
+		t.Errorf("expected akeneo to be healthy, got %v: %v", status.AppStateInfo.State, status.AppStateInfo.StateReason)
+	}
+
+	if len(requested) != 2 || requested[0] != "/api/v1/namespaces/shop/services/flamingo:8080/proxy/health" || requested[1] != "/api/v1/namespaces/shop/services/akeneo:80/proxy/" {
+		t.Errorf("expected the healthchecks through the service proxy, got %v", requested)
+	}
+
+	postgres := &vistectureCore.Application{Name: "postgres", Properties: map[string]string{"deployment": "kubernetes", "healthCheckType": "tcp"}}
+	status = checkAppStatusInKubernetes(t.Context(), nil, postgres, resources)
+	if status.AppStateInfo.State != State_unknown || status.AppStateInfo.StateReason != "healthCheckType tcp is not supported for the services of another cluster" {
+		t.Errorf("expected the tcp check to be unsupported, got %v: %v", status.AppStateInfo.State, status.AppStateInfo.StateReason)
+	}
+}
@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// AlertmanagerNotifier pushes an alert for every app whose own check enters the failed or unhealthy state to the Alertmanager api and resolves it once the app recovers.
+	// Impacted apps are alerted by their own check only, so only the root cause fires unless the impacted app is down itself.
+	// Firing alerts are resent regularly, as Alertmanager resolves alerts that are not resent.
+	AlertmanagerNotifier struct {
+		// URL is the base URL of the Alertmanager, e.g. http://alertmanager:9093
+		URL string
+		// PublicURL is the URL the dashboard is reachable at, to link to the app page
+		PublicURL string
+		Client    *http.Client
+		mu        sync.Mutex
+		firing    map[string]alertmanagerAlert
+	}
+
+	// alertmanagerAlert is a postable alert of the Alertmanager api v2
+	alertmanagerAlert struct {
+		Labels       map[string]string `json:"labels"`
+		Annotations  map[string]string `json:"annotations,omitempty"`
+		StartsAt     time.Time         `json:"startsAt"`
+		EndsAt       time.Time         `json:"endsAt"`
+		GeneratorURL string            `json:"generatorURL,omitempty"`
+	}
+)
+
+const (
+	alertName = "VistectureApplicationDown"
+	// alertResendInterval is the interval in which the firing alerts are resent, they end after 4 intervals without resend
+	alertResendInterval = time.Minute
+)
+
+var (
+	_ Notifier    = new(AlertmanagerNotifier)
+	_ StateSyncer = new(AlertmanagerNotifier)
+)
+
+// NewAlertmanagerNotifier creates a notifier pushing to the Alertmanager at the base URL
+func NewAlertmanagerNotifier(alertmanagerURL string, publicURL string) *AlertmanagerNotifier {
+	return &AlertmanagerNotifier{
+		URL:       strings.TrimSuffix(alertmanagerURL, "/"),
+		PublicURL: strings.TrimSuffix(publicURL, "/"),
+		Client:    http.DefaultClient,
+		firing:    make(map[string]alertmanagerAlert),
+	}
+}
+
+// Notify fires an alert if the own check of the app is failed or unhealthy now, and resolves the alert of its former state
+func (a *AlertmanagerNotifier) Notify(ctx context.Context, transition Transition) error {
+	var alerts []alertmanagerAlert
+
+	state := transition.ToOwnState()
+
+	a.mu.Lock()
+	key := transition.Environment + "/" + transition.Application
+	alert, firing := a.firing[key]
+	// the alert keeps firing while the own check of the app stays in the same broken state
+	if firing && alert.Labels["state"] != state {
+		alert.EndsAt = transition.Time
+		alerts = append(alerts, alert)
+		delete(a.firing, key)
+		firing = false
+	}
+	if !firing && isBroken(state) {
+		alert := a.alert(transition)
+		a.firing[key] = alert
+		alerts = append(alerts, alert)
+	}
+	a.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+	return a.post(ctx, alerts)
+}
+
+// SyncState fires the alerts of the apps whose own check is failed or unhealthy when the dashboard starts
+func (a *AlertmanagerNotifier) SyncState(ctx context.Context, transitions []Transition) error {
+	var alerts []alertmanagerAlert
+
+	a.mu.Lock()
+	for _, transition := range transitions {
+		key := transition.Environment + "/" + transition.Application
+		if _, ok := a.firing[key]; ok || !isBroken(transition.ToOwnState()) {
+			continue
+		}
+		alert := a.alert(transition)
+		a.firing[key] = alert
+		alerts = append(alerts, alert)
+	}
+	a.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+	return a.post(ctx, alerts)
+}
+
+// ResendInRegularInterval resends the firing alerts, it never returns
+func (a *AlertmanagerNotifier) ResendInRegularInterval() {
+	for now := range time.Tick(alertResendInterval) {
+		if err := a.resend(now); err != nil {
+			log.Printf("Notify: resending the alerts to %v failed: %v", a.URL, err)
+		}
+	}
+}
+
+// resend extends the end of the firing alerts and posts them
+func (a *AlertmanagerNotifier) resend(now time.Time) error {
+	a.mu.Lock()
+	alerts := make([]alertmanagerAlert, 0, len(a.firing))
+	for key, alert := range a.firing {
+		alert.EndsAt = now.Add(4 * alertResendInterval)
+		a.firing[key] = alert
+		alerts = append(alerts, alert)
+	}
+	a.mu.Unlock()
+
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	return a.post(ctx, alerts)
+}
+
+// alert creates the firing alert of the transition
+func (a *AlertmanagerNotifier) alert(transition Transition) alertmanagerAlert {
+	state := transition.ToOwnState()
+	severity := "warning"
+	if state == kube.StateName(kube.State_failed) {
+		severity = "critical"
+	}
+
+	alert := alertmanagerAlert{
+		Labels: map[string]string{
+			"alertname":   alertName,
+			"application": transition.Application,
+			"state":       state,
+			"environment": transition.Environment,
+			"severity":    severity,
+		},
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("%s is %s", transition.Application, state),
+		},
+		// the time the state changed, not the time it is sent after the debounce period
+		StartsAt: transition.Time,
+		EndsAt:   transition.Time.Add(4 * alertResendInterval),
+	}
+	if transition.Team != "" {
+		alert.Labels["team"] = transition.Team
+	}
+	if transition.Namespace != "" {
+		alert.Labels["namespace"] = transition.Namespace
+	}
+	if transition.Reason != "" {
+		alert.Annotations["description"] = transition.Reason
+	}
+	if a.PublicURL != "" {
+		alert.GeneratorURL = fmt.Sprintf("%s/app/%s?environment=%s", a.PublicURL, url.PathEscape(transition.Application), url.QueryEscape(transition.Environment))
+	}
+
+	return alert
+}
+
+func (a *AlertmanagerNotifier) post(ctx context.Context, alerts []alertmanagerAlert) error {
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, a.Client, a.URL+"/api/v2/alerts", body)
+}
+
+// isBroken is true for the states alerted, failed and unhealthy
+func isBroken(state string) bool {
+	s, ok := kube.StateByName(state)
+	return ok && kube.IsBroken(s)
+}
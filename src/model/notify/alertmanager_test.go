@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func TestAlertmanagerNotifier(t *testing.T) {
+	var posted [][]alertmanagerAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/alerts" {
+			t.Errorf("unexpected path %v", r.URL.Path)
+		}
+		var alerts []alertmanagerAlert
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			t.Errorf("invalid alerts: %v", err)
+		}
+		posted = append(posted, alerts)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	a := NewAlertmanagerNotifier(server.URL+"/", "https://dashboard.example.com")
+
+	err := a.SyncState(context.Background(), []Transition{
+		{Time: now, Environment: "prod", Application: "akeneo", Namespace: "pim", From: "unknown", To: "failed", Reason: "No pod available"},
+		{Time: now, Environment: "prod", Application: "keycloak", From: "unknown", To: "healthy"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 1 || len(posted[0]) != 1 {
+		t.Fatalf("expected the alert of akeneo, got %+v", posted)
+	}
+	alert := posted[0][0]
+	if alert.Labels["application"] != "akeneo" || alert.Labels["state"] != "failed" || alert.Labels["namespace"] != "pim" || alert.Labels["severity"] != "critical" ||
+		alert.Annotations["description"] != "No pod available" || alert.GeneratorURL != "https://dashboard.example.com/app/akeneo?environment=prod" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	// unhealthy now: the failed alert is resolved and an alert for the new state fired
+	err = a.Notify(context.Background(), Transition{Time: now.Add(time.Minute), Environment: "prod", Application: "akeneo", Team: "Team 2", From: "failed", To: "unhealthy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 2 || len(posted[1]) != 2 || !posted[1][0].EndsAt.Equal(now.Add(time.Minute)) || posted[1][1].Labels["state"] != "unhealthy" || posted[1][1].Labels["team"] != "Team 2" {
+		t.Fatalf("expected the failed alert resolved and the unhealthy one fired, got %+v", posted[1])
+	}
+
+	if err := a.resend(now.Add(2 * time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 3 || len(posted[2]) != 1 || !posted[2][0].EndsAt.Equal(now.Add(6*time.Minute)) {
+		t.Fatalf("expected the firing alert to be resent, got %+v", posted[2])
+	}
+
+	err = a.Notify(context.Background(), Transition{Time: now.Add(3 * time.Minute), Environment: "prod", Application: "akeneo", From: "unhealthy", To: "healthy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 4 || len(posted[3]) != 1 || !posted[3][0].EndsAt.Equal(now.Add(3*time.Minute)) {
+		t.Fatalf("expected the alert to be resolved, got %+v", posted[3])
+	}
+	if err := a.resend(now.Add(4 * time.Minute)); err != nil || len(posted) != 4 {
+		t.Errorf("expected nothing to resend after the recovery, got %v %+v", err, posted)
+	}
+}
+
+func TestAlertmanagerNotifier_OwnState(t *testing.T) {
+	var posted [][]alertmanagerAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []alertmanagerAlert
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			t.Errorf("invalid alerts: %v", err)
+		}
+		posted = append(posted, alerts)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	a := NewAlertmanagerNotifier(server.URL, "")
+
+	// impacted by a dependency, but its own check is fine
+	err := a.Notify(context.Background(), Transition{Time: now, Environment: "prod", Application: "flamingo", From: "healthy", To: "impacted", OwnState: "healthy"})
+	if err != nil || len(posted) != 0 {
+		t.Fatalf("expected no alert for an impacted app with a healthy own check, got %v %+v", err, posted)
+	}
+
+	err = a.Notify(context.Background(), Transition{Time: now.Add(time.Minute), Environment: "prod", Application: "flamingo", From: "impacted", FromOwnState: "healthy", To: "impacted", OwnState: "unhealthy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 1 || len(posted[0]) != 1 || posted[0][0].Labels["state"] != "unhealthy" || posted[0][0].Labels["severity"] != "warning" {
+		t.Fatalf("expected an alert for the unhealthy own check, got %+v", posted)
+	}
+
+	// the dependency recovers, the app is still unhealthy itself
+	err = a.Notify(context.Background(), Transition{Time: now.Add(2 * time.Minute), Environment: "prod", Application: "flamingo", From: "impacted", FromOwnState: "unhealthy", To: "unhealthy"})
+	if err != nil || len(posted) != 1 {
+		t.Fatalf("expected the alert to keep firing, got %v %+v", err, posted)
+	}
+
+	err = a.Notify(context.Background(), Transition{Time: now.Add(3 * time.Minute), Environment: "prod", Application: "flamingo", From: "unhealthy", To: "healthy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posted) != 2 || len(posted[1]) != 1 || !posted[1][0].EndsAt.Equal(now.Add(3*time.Minute)) {
+		t.Errorf("expected the alert to be resolved, got %+v", posted)
+	}
+}
+
+func TestAlertmanagerNotifier_StartsAtFirstChange(t *testing.T) {
+	var posted []alertmanagerAlert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []alertmanagerAlert
+		if err := json.NewDecoder(r.Body).Decode(&alerts); err != nil {
+			t.Errorf("invalid alerts: %v", err)
+		}
+		posted = append(posted, alerts...)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	d := NewDispatcher([]Notifier{NewAlertmanagerNotifier(server.URL, "")}, time.Minute)
+	d.Update(kube.StatusUpdate{Time: now, Transitions: []kube.StateTransition{stateTransition("flamingo", kube.State_healthy, kube.State_unhealthy)}})
+	d.Update(kube.StatusUpdate{Time: now.Add(30 * time.Second), Transitions: []kube.StateTransition{stateTransition("flamingo", kube.State_unhealthy, kube.State_failed)}})
+	d.flush(now.Add(2 * time.Minute))
+
+	if len(posted) != 1 || posted[0].Labels["state"] != "failed" || !posted[0].StartsAt.Equal(now) {
+		t.Errorf("expected the alert to start with the first change instead of the debounced send, got %+v", posted)
+	}
+}
@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// ChatNotifier posts the transitions as Slack incoming webhook messages (also accepted by Mattermost) to the webhooks of the team of the app
+	ChatNotifier struct {
+		// Teams maps the vistecture teams to their webhooks, Default is used for apps of other teams or without team
+		Teams   map[string][]string
+		Default []string
+		// PublicURL is the URL the dashboard is reachable at, to link to the app page
+		PublicURL string
+		Client    *http.Client
+	}
+
+	chatMessage struct {
+		Text        string           `json:"text"`
+		Attachments []chatAttachment `json:"attachments,omitempty"`
+	}
+
+	chatAttachment struct {
+		Fallback  string      `json:"fallback"`
+		Color     string      `json:"color"`
+		Title     string      `json:"title"`
+		TitleLink string      `json:"title_link,omitempty"`
+		Text      string      `json:"text,omitempty"`
+		Fields    []chatField `json:"fields,omitempty"`
+	}
+
+	chatField struct {
+		Title string `json:"title"`
+		Value string `json:"value"`
+		Short bool   `json:"short"`
+	}
+)
+
+var _ Notifier = new(ChatNotifier)
+
+// NewChatNotifier creates a notifier from webhooks given as "team=url", or just "url" for the default
+func NewChatNotifier(webhooks []string, publicURL string) *ChatNotifier {
+	c := &ChatNotifier{Teams: make(map[string][]string), PublicURL: strings.TrimSuffix(publicURL, "/"), Client: http.DefaultClient}
+	for _, webhook := range webhooks {
+		team, webhookURL, found := strings.Cut(webhook, "=")
+		if !found || strings.Contains(team, "://") {
+			c.Default = append(c.Default, webhook)
+			continue
+		}
+		c.Teams[team] = append(c.Teams[team], webhookURL)
+	}
+
+	return c
+}
+
+// Notify posts the message to all webhooks of the team of the app
+func (c *ChatNotifier) Notify(ctx context.Context, transition Transition) error {
+	webhooks, ok := c.Teams[transition.Team]
+	if !ok {
+		webhooks = c.Default
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(c.message(transition))
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		errs = append(errs, postJSON(ctx, c.Client, webhook, body))
+	}
+	return errors.Join(errs...)
+}
+
+// message formats the transition, with the reason and a link to the app page if the PublicURL is set
+func (c *ChatNotifier) message(transition Transition) chatMessage {
+	title := fmt.Sprintf("%s is %s", transition.Application, transition.To)
+	if transition.OwnState != "" {
+		title += " (own check " + transition.OwnState + ")"
+	}
+
+	link := ""
+	if c.PublicURL != "" {
+		link = fmt.Sprintf("%s/app/%s?environment=%s", c.PublicURL, url.PathEscape(transition.Application), url.QueryEscape(transition.Environment))
+	}
+
+	text := fmt.Sprintf("%s %s: %s → %s", stateEmoji(transition.To), transition.Application, transition.From, transition.To)
+	if link != "" {
+		text += fmt.Sprintf(" (<%s|details>)", link)
+	}
+
+	fields := []chatField{{Title: "Environment", Value: transition.Environment, Short: true}}
+	if transition.Team != "" {
+		fields = append(fields, chatField{Title: "Team", Value: transition.Team, Short: true})
+	}
+	if transition.Namespace != "" {
+		fields = append(fields, chatField{Title: "Namespace", Value: transition.Namespace, Short: true})
+	}
+
+	return chatMessage{
+		Text: text,
+		Attachments: []chatAttachment{{
+			Fallback:  text,
+			Color:     stateColor(transition.To),
+			Title:     title,
+			TitleLink: link,
+			Text:      transition.Reason,
+			Fields:    fields,
+		}},
+	}
+}
+
+// stateEmoji returns the emoji shown in front of the message
+func stateEmoji(state string) string {
+	switch state {
+	case kube.StateName(kube.State_failed), kube.StateName(kube.State_unhealthy):
+		return ":red_circle:"
+	case kube.StateName(kube.State_healthy):
+		return ":large_green_circle:"
+	}
+	return ":large_orange_circle:"
+}
+
+// stateColor returns the color of the attachment
+func stateColor(state string) string {
+	switch state {
+	case kube.StateName(kube.State_failed), kube.StateName(kube.State_unhealthy):
+		return "#d32f2f"
+	case kube.StateName(kube.State_healthy):
+		return "#388e3c"
+	}
+	return "#f57c00"
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestChatNotifier_RoutesByTeam(t *testing.T) {
+	received := make(map[string][]chatMessage)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message chatMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("invalid message: %v", err)
+		}
+		received[r.URL.Path] = append(received[r.URL.Path], message)
+	}))
+	defer server.Close()
+
+	c := NewChatNotifier([]string{
+		"Team 1=" + server.URL + "/team1",
+		server.URL + "/default?token=a=b",
+	}, "https://dashboard.example.com/")
+
+	transition := Transition{Environment: "prod", Application: "flamingo", Team: "Team 1", From: "healthy", To: "failed", Reason: "No pod available"}
+	if err := c.Notify(context.Background(), transition); err != nil {
+		t.Fatal(err)
+	}
+	transition.Team = "Team 2"
+	transition.Application = "akeneo"
+	if err := c.Notify(context.Background(), transition); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(received["/team1"]) != 1 || len(received["/default"]) != 1 {
+		t.Fatalf("expected a message per webhook, got %+v", received)
+	}
+
+	message := received["/team1"][0]
+	expectedText := ":red_circle: flamingo: healthy → failed (<https://dashboard.example.com/app/flamingo?environment=prod|details>)"
+	if message.Text != expectedText {
+		t.Errorf("expected the text %q, got %q", expectedText, message.Text)
+	}
+	attachment := message.Attachments[0]
+	if attachment.Title != "flamingo is failed" || attachment.Text != "No pod available" || attachment.TitleLink != "https://dashboard.example.com/app/flamingo?environment=prod" {
+		t.Errorf("unexpected attachment %+v", attachment)
+	}
+	if received["/default"][0].Attachments[0].Title != "akeneo is failed" {
+		t.Errorf("expected the app of Team 2 at the default webhook, got %+v", received["/default"])
+	}
+}
+
+func TestChatNotifier_NoRoute(t *testing.T) {
+	c := NewChatNotifier([]string{"Team 1=http://127.0.0.1:1/unused"}, "")
+	if err := c.Notify(context.Background(), Transition{Application: "akeneo", Team: "Team 2"}); err != nil {
+		t.Errorf("expected apps of teams without webhook to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+type (
+	// Transition is a change of the state of an application, as sent to the notifiers
+	Transition struct {
+		// Time is the time of the first change merged into the transition
+		Time        time.Time `json:"time"`
+		Environment string    `json:"environment"`
+		// Application is the name of the vistecture app, Name the one of the kubernetes resource
+		Application string `json:"application"`
+		Name        string `json:"name"`
+		Namespace   string `json:"namespace,omitempty"`
+		Team        string `json:"team,omitempty"`
+		From        string `json:"from"`
+		To          string `json:"to"`
+		// OwnState is the state of the app's own check if it is impacted by a dependency, FromOwnState the one before if it was impacted
+		OwnState     string `json:"ownState,omitempty"`
+		FromOwnState string `json:"fromOwnState,omitempty"`
+		Reason       string `json:"reason,omitempty"`
+	}
+
+	// Notifier sends a transition somewhere
+	Notifier interface {
+		Notify(ctx context.Context, transition Transition) error
+	}
+
+	// StateSyncer is implemented by notifiers that need the state of the apps after their first check, as no transitions are detected then.
+	// E.g. to fire the alerts of apps that are failing already when the dashboard starts.
+	StateSyncer interface {
+		SyncState(ctx context.Context, transitions []Transition) error
+	}
+
+	// Dispatcher collects the transitions of the status fetchers and passes them to the notifiers once the app did not change for the debounce period.
+	// If an app changes its state again within the period only the overall transition is sent, none if it is back to its former state.
+	// An app that keeps changing is sent after maxDelayFactor debounce periods at the latest.
+	Dispatcher struct {
+		notifiers []Notifier
+		debounce  time.Duration
+		mu        sync.Mutex
+		pending   map[string]*pendingTransition
+		// checked holds the apps seen already, unsynced the first states of the others waiting for the next flush
+		checked  map[string]bool
+		unsynced []Transition
+	}
+
+	pendingTransition struct {
+		// since is the time of the first transition and last the one of the latest, the overall transition is sent once the app
+		// did not change for the debounce period or it is pending for maxDelayFactor debounce periods
+		since, last time.Time
+		transition  Transition
+	}
+)
+
+const (
+	// notifyTimeout limits the time a notifier may take for a transition
+	notifyTimeout = 10 * time.Second
+	// maxDelayFactor limits the time a transition is pending to this number of debounce periods, so a flapping app is notified as well
+	maxDelayFactor = 3
+)
+
+// NewDispatcher creates a dispatcher passing the transitions to the notifiers once they are stable for the debounce period
+func NewDispatcher(notifiers []Notifier, debounce time.Duration) *Dispatcher {
+	return &Dispatcher{
+		notifiers: notifiers,
+		debounce:  debounce,
+		pending:   make(map[string]*pendingTransition),
+		checked:   make(map[string]bool),
+	}
+}
+
+// Update collects the transitions of an update of a status fetcher, it is registered with StatusFetcher.OnUpdate.
+// Nothing is sent here: the apps checked for the first time are passed to the notifiers implementing StateSyncer
+// and the transitions to all notifiers by the next flush.
+func (d *Dispatcher) Update(update kube.StatusUpdate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, app := range update.Changed {
+		key := app.Environment + "/" + app.VistectureApp.Name
+		if !d.checked[key] {
+			d.checked[key] = true
+			d.unsynced = append(d.unsynced, newTransition(kube.StateTransition{Previous: kube.State_unknown, App: app}, update.Time))
+		}
+	}
+	for _, transition := range update.Transitions {
+		d.add(newTransition(transition, update.Time))
+	}
+}
+
+// FlushInRegularInterval sends the first states and the transitions stable for the debounce period, it never returns
+func (d *Dispatcher) FlushInRegularInterval() {
+	interval := min(max(d.debounce/10, time.Second), 30*time.Second)
+	for now := range time.Tick(interval) {
+		d.flush(now)
+	}
+}
+
+// add merges the transition into the pending transition of the app and restarts its debounce period, the caller has to hold the lock
+func (d *Dispatcher) add(transition Transition) {
+	key := transition.Environment + "/" + transition.Application
+	if pending, ok := d.pending[key]; ok {
+		transition.From = pending.transition.From
+		transition.FromOwnState = pending.transition.FromOwnState
+		pending.last = transition.Time
+		transition.Time = pending.since
+		pending.transition = transition
+		return
+	}
+
+	d.pending[key] = &pendingTransition{since: transition.Time, last: transition.Time, transition: transition}
+}
+
+// flush syncs the first states of the apps and sends the transitions of the apps that did not change for the debounce period
+// or are pending for maxDelayFactor debounce periods
+func (d *Dispatcher) flush(now time.Time) {
+	var due []Transition
+
+	d.mu.Lock()
+	unsynced := d.unsynced
+	d.unsynced = nil
+	for key, pending := range d.pending {
+		if now.Sub(pending.last) < d.debounce && now.Sub(pending.since) < maxDelayFactor*d.debounce {
+			continue
+		}
+		delete(d.pending, key)
+		if pending.transition.changed() {
+			due = append(due, pending.transition)
+		}
+	}
+	d.mu.Unlock()
+
+	if len(unsynced) > 0 {
+		d.syncState(unsynced)
+	}
+	for _, transition := range due {
+		for _, notifier := range d.notifiers {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			if err := notifier.Notify(ctx, transition); err != nil {
+				log.Printf("Notify: sending %v -> %v of %v failed: %v", transition.From, transition.To, transition.Application, err)
+			}
+			cancel()
+		}
+	}
+}
+
+// syncState passes the first states of the apps to the notifiers implementing StateSyncer
+func (d *Dispatcher) syncState(transitions []Transition) {
+	for _, notifier := range d.notifiers {
+		if syncer, ok := notifier.(StateSyncer); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			if err := syncer.SyncState(ctx, transitions); err != nil {
+				log.Printf("Notify: syncing the state failed: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// newTransition converts the transition detected by the status fetcher
+func newTransition(transition kube.StateTransition, now time.Time) Transition {
+	app := transition.App
+	t := Transition{
+		Time:        now,
+		Environment: app.Environment,
+		Application: app.VistectureApp.Name,
+		Name:        app.Name,
+		Namespace:   app.Namespace,
+		Team:        app.VistectureApp.Team,
+		From:        kube.StateName(transition.Previous),
+		To:          kube.StateName(app.AppStateInfo.State),
+		Reason:      app.AppStateInfo.StateReason,
+	}
+	if app.AppStateInfo.State == kube.State_impacted {
+		t.OwnState = kube.StateName(app.AppStateInfo.OwnState)
+	}
+	if transition.Previous == kube.State_impacted {
+		t.FromOwnState = kube.StateName(transition.PreviousOwnState)
+	}
+
+	return t
+}
+
+// ToOwnState returns the state of the app's own check after the transition, it differs from To if the app is impacted
+func (t Transition) ToOwnState() string {
+	if t.OwnState != "" {
+		return t.OwnState
+	}
+	return t.To
+}
+
+// changed is false if the app is back in its former state, including the state of its own check
+func (t Transition) changed() bool {
+	fromOwnState := t.FromOwnState
+	if fromOwnState == "" {
+		fromOwnState = t.From
+	}
+	return t.From != t.To || fromOwnState != t.ToOwnState()
+}
@@ -0,0 +1,202 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	vistectureCore "github.com/AOEpeople/vistecture/v2/model/core"
+
+	"github.com/AOEpeople/vistecture-dashboard/v2/src/model/kube"
+)
+
+func stateTransition(app string, from, to uint) kube.StateTransition {
+	return kube.StateTransition{
+		Previous: from,
+		App: kube.AppDeploymentInfo{
+			Name:          app,
+			Namespace:     "default",
+			Environment:   "prod",
+			VistectureApp: vistectureCore.Application{Name: app, Team: "Team 1"},
+			AppStateInfo:  kube.AppStateInfo{State: to, StateReason: "reason of " + kube.StateName(to)},
+		},
+	}
+}
+
+func TestDispatcher_Debounce(t *testing.T) {
+	var mu sync.Mutex
+	var received []Transition
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var transition Transition
+		if err := json.NewDecoder(r.Body).Decode(&transition); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected a JSON content type, got %q", r.Header.Get("Content-Type"))
+		}
+		mu.Lock()
+		received = append(received, transition)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	d := NewDispatcher([]Notifier{NewWebhookNotifier(server.URL)}, time.Minute)
+
+	d.Update(kube.StatusUpdate{Time: now, Transitions: []kube.StateTransition{
+		stateTransition("flamingo", kube.State_healthy, kube.State_failed),
+		stateTransition("akeneo", kube.State_healthy, kube.State_unhealthy),
+	}})
+	// akeneo flaps back, flamingo recovers partially
+	d.Update(kube.StatusUpdate{Time: now.Add(20 * time.Second), Transitions: []kube.StateTransition{
+		stateTransition("flamingo", kube.State_failed, kube.State_unhealthy),
+		stateTransition("akeneo", kube.State_unhealthy, kube.State_healthy),
+	}})
+	d.Update(kube.StatusUpdate{Time: now.Add(90 * time.Second), Transitions: []kube.StateTransition{
+		stateTransition("keycloak", kube.State_unstable, kube.State_healthy),
+	}})
+
+	// the second change of flamingo restarted its debounce period
+	d.flush(now.Add(time.Minute))
+	if len(received) != 0 {
+		t.Fatalf("expected no notification within the debounce period, got %+v", received)
+	}
+
+	d.flush(now.Add(80 * time.Second))
+	expected := []Transition{{
+		Time:        now,
+		Environment: "prod",
+		Application: "flamingo",
+		Name:        "flamingo",
+		Namespace:   "default",
+		Team:        "Team 1",
+		From:        "healthy",
+		To:          "unhealthy",
+		Reason:      "reason of unhealthy",
+	}}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected only the overall transition of flamingo %+v, got %+v", expected, received)
+	}
+
+	d.flush(now.Add(3 * time.Minute))
+	if len(received) != 2 || received[1].Application != "keycloak" || received[1].From != "unstable" {
+		t.Errorf("expected the transition of keycloak, got %+v", received)
+	}
+}
+
+func TestDispatcher_FlappingAppNotified(t *testing.T) {
+	recorder := new(notifyRecorder)
+	d := NewDispatcher([]Notifier{recorder}, time.Minute)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	// the app crash-loops between failed and unhealthy faster than the debounce period
+	previous := uint(kube.State_healthy)
+	for i := range 8 {
+		at := now.Add(time.Duration(i) * 30 * time.Second)
+		next := uint(kube.State_failed)
+		if previous == kube.State_failed {
+			next = kube.State_unhealthy
+		}
+		d.Update(kube.StatusUpdate{Time: at, Transitions: []kube.StateTransition{stateTransition("flamingo", previous, next)}})
+		d.flush(at)
+		previous = next
+	}
+
+	if len(recorder.notified) != 1 {
+		t.Fatalf("expected the flapping app to be notified after the maximum delay, got %+v", recorder.notified)
+	}
+	if transition := recorder.notified[0]; transition.From != "healthy" || transition.To != "failed" {
+		t.Errorf("expected the overall transition from healthy, got %+v", transition)
+	}
+}
+
+func TestWebhookNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), Transition{}); err == nil {
+		t.Error("expected an error for status 502")
+	}
+}
+
+// notifyRecorder records the transitions passed to Notify
+type notifyRecorder struct {
+	notified []Transition
+}
+
+func (n *notifyRecorder) Notify(_ context.Context, transition Transition) error {
+	n.notified = append(n.notified, transition)
+	return nil
+}
+
+// syncRecorder records the transitions passed to SyncState
+type syncRecorder struct {
+	synced []Transition
+}
+
+func (s *syncRecorder) Notify(context.Context, Transition) error { return nil }
+
+func (s *syncRecorder) SyncState(_ context.Context, transitions []Transition) error {
+	s.synced = append(s.synced, transitions...)
+	return nil
+}
+
+func TestDispatcher_SyncsAppsCheckedFirst(t *testing.T) {
+	recorder := new(syncRecorder)
+	d := NewDispatcher([]Notifier{recorder}, time.Minute)
+
+	// the apps are published one by one, flamingo changes again in the next cycle
+	flamingo := stateTransition("flamingo", kube.State_unknown, kube.State_failed).App
+	akeneo := stateTransition("akeneo", kube.State_unknown, kube.State_healthy).App
+	d.Update(kube.StatusUpdate{Time: time.Now(), Changed: []kube.AppDeploymentInfo{flamingo}})
+	d.Update(kube.StatusUpdate{Time: time.Now(), Changed: []kube.AppDeploymentInfo{akeneo}})
+	if len(recorder.synced) != 0 {
+		t.Fatalf("expected the states to be synced by flush, got %+v", recorder.synced)
+	}
+
+	d.flush(time.Now())
+	d.Update(kube.StatusUpdate{Time: time.Now(), Changed: []kube.AppDeploymentInfo{flamingo}})
+	d.flush(time.Now())
+
+	if len(recorder.synced) != 2 || recorder.synced[0].Application != "flamingo" || recorder.synced[1].Application != "akeneo" {
+		t.Errorf("expected flamingo and akeneo to be synced once, got %+v", recorder.synced)
+	}
+}
+
+func TestDispatcher_OwnStateOfImpactedApps(t *testing.T) {
+	recorder := new(notifyRecorder)
+	d := NewDispatcher([]Notifier{recorder}, time.Minute)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	impacted := func(app string, previousOwnState, ownState uint) kube.StateTransition {
+		transition := stateTransition(app, kube.State_impacted, kube.State_impacted)
+		transition.PreviousOwnState = previousOwnState
+		transition.App.AppStateInfo.OwnState = ownState
+		return transition
+	}
+
+	// flamingo degrades itself while impacted, akeneo degrades and recovers within the debounce period
+	d.Update(kube.StatusUpdate{Time: now, Transitions: []kube.StateTransition{
+		impacted("flamingo", kube.State_healthy, kube.State_degraded),
+		impacted("akeneo", kube.State_healthy, kube.State_degraded),
+	}})
+	d.Update(kube.StatusUpdate{Time: now.Add(10 * time.Second), Transitions: []kube.StateTransition{
+		impacted("akeneo", kube.State_degraded, kube.State_healthy),
+	}})
+	d.flush(now.Add(2 * time.Minute))
+
+	if len(recorder.notified) != 1 {
+		t.Fatalf("expected only the transition of flamingo, got %+v", recorder.notified)
+	}
+	transition := recorder.notified[0]
+	if transition.Application != "flamingo" || transition.From != "impacted" || transition.FromOwnState != "healthy" || transition.OwnState != "degraded" {
+		t.Errorf("expected the change of the own state of flamingo, got %+v", transition)
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// WebhookNotifier posts every transition as JSON to the URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+var _ Notifier = new(WebhookNotifier)
+
+// NewWebhookNotifier creates a notifier posting to the url with the default http client
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: http.DefaultClient}
+}
+
+// Notify posts the transition, any status other than 2xx is an error
+func (w *WebhookNotifier) Notify(ctx context.Context, transition Transition) error {
+	body, err := json.Marshal(transition)
+	if err != nil {
+		return err
+	}
+
+	return postJSON(ctx, w.Client, w.URL, body)
+}
+
+// postJSON posts the body and checks the response status
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "VistectureDashboard")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v returned status %v", url, resp.Status)
+	}
+	return nil
+}
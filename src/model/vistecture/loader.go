@@ -23,3 +23,36 @@ func LoadProject(projectConfigFile string) *vistectureCore.Project {
 	log.Printf("Loaded %v apps for project %v", len(completeProject.Applications), definitions.ProjectName)
 	return completeProject
 }
+
+// SubView is a named part of the project as configured in the subViews of the project config
+type SubView struct {
+	Name string
+	// Applications are the names of the apps included in the sub view
+	Applications []string
+}
+
+// LoadSubViews loads the apps of all sub views of the project
+func LoadSubViews(projectConfigFile string) []SubView {
+	loader := application.ProjectLoader{}
+	definitions, err := loader.LoadProjectConfig(projectConfigFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	subViews := make([]SubView, 0, len(definitions.SubViews))
+	for _, subViewConfig := range definitions.SubViews {
+		subProject, err := loader.LoadProject(definitions, path.Dir(projectConfigFile), subViewConfig.Name)
+		if err != nil {
+			log.Fatalf("Sub view %v is not valid: %v", subViewConfig.Name, err)
+		}
+
+		subView := SubView{Name: subViewConfig.Name}
+		for _, app := range subProject.Applications {
+			subView.Applications = append(subView.Applications, app.Name)
+		}
+		subViews = append(subViews, subView)
+	}
+	log.Printf("Loaded %v sub views", len(subViews))
+
+	return subViews
+}
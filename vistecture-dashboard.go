@@ -26,6 +26,10 @@ func main() {
 	_ = flag.Set("alsologtostderr", "true")
 
 	var ignoredServices listFlag
+	var namespaces listFlag
+	var contexts listFlag
+	var webhooks listFlag
+	var chatWebhooks listFlag
 
 	d := &interfaces.DashboardController{}
 	flag.StringVar(&d.ProjectPath, "config", "example/project.yml", "Path to project config")
@@ -33,10 +37,35 @@ func main() {
 	flag.StringVar(&d.Listen, "Listen", ":8080", "server Listen address")
 	flag.Var(&ignoredServices, "ignore", "services to exclude from checks")
 	flag.BoolVar(&d.DemoMode, "Demo", false, "Demo mode (for templating, demo)")
+	flag.Var(&namespaces, "namespace", "kubernetes namespaces to check, the first is the default for apps without k8sNamespace (default: namespace of the kubeconfig)")
+	flag.BoolVar(&d.AllNamespaces, "all-namespaces", false, "check apps in all kubernetes namespaces")
+	flag.IntVar(&d.CronJobMissedPeriods, "cronjob-missed-periods", 2, "number of schedule periods without successful run after which a CronJob is degraded")
+	flag.IntVar(&d.CheckConcurrency, "check-concurrency", 10, "number of apps checked at the same time")
+	flag.DurationVar(&d.CheckJitter, "check-jitter", time.Second, "maximum random wait before the check of an app, to spread the checks")
+	flag.DurationVar(&d.CheckTimeout, "check-timeout", 15*time.Second, "time the check of an app may take, including the check through the ingress")
+	flag.StringVar(&d.HistoryFile, "history-file", "", "database file to persist the state changes and check results of the apps, e.g. on a mounted volume (default: no history)")
+	flag.DurationVar(&d.HistoryRetention, "history-retention", 30*24*time.Hour, "how long the state changes are kept in the history file")
+	flag.DurationVar(&d.HistoryCheckRetention, "history-check-retention", 30*24*time.Hour, "how long the results of every check are kept in the history file")
+	flag.Var(&webhooks, "webhook", "URL to post the state transitions of the apps to as JSON, can be given multiple times")
+	flag.Var(&chatWebhooks, "chat-webhook", "Slack or Mattermost incoming webhook for the transitions of the apps of a team as team=url, or just url for all other teams, can be given multiple times")
+	flag.StringVar(&d.PublicURL, "public-url", "", "URL the dashboard is reachable at, to link to the apps in notifications")
+	flag.StringVar(&d.AlertmanagerURL, "alertmanager-url", "", "URL of the Alertmanager to push alerts for failed and unhealthy apps to, e.g. http://alertmanager:9093")
+	flag.DurationVar(&d.NotifyDebounce, "notify-debounce", 2*time.Minute, "how long a state has to be stable before it is notified")
+	flag.Var(&contexts, "context", "kubeconfig context to check as environment, as name=context or just context (default: current context)")
 
 	flag.Parse()
 
 	d.IgnoredServices = ignoredServices
+	d.Namespaces = namespaces
+	d.Webhooks = webhooks
+	d.ChatWebhooks = chatWebhooks
+	for _, context := range contexts {
+		name, kubeContext, found := strings.Cut(context, "=")
+		if !found {
+			kubeContext = name
+		}
+		d.Environments = append(d.Environments, interfaces.Environment{Name: name, KubeContext: kubeContext})
+	}
 
 	http.DefaultClient.Timeout = 10 * time.Second
 